@@ -0,0 +1,190 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// DefaultAchievementsFile returns the path of the unlocked achievements
+// in the user's config directory.
+func DefaultAchievementsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "achievements.json")
+}
+
+// achievement is a goal of a run, unlocked once it is reached.
+type achievement struct {
+	id, name, desc string
+	// reached reports whether the run reached the goal. It is checked
+	// after every tick.
+	reached func(g *Game) bool
+}
+
+var achievements = []achievement{
+	{"length50", "Length 50", "grow the snake to 50 cells", func(g *Game) bool {
+		return g.sim.Snake().Len() >= 50
+	}},
+	{"survive5", "Survivor", "survive for 5 minutes", func(g *Game) bool {
+		return float64(g.sim.Frame())/g.clock.rate() >= 5*60
+	}},
+	{"bonus3", "Sweet Tooth", "eat 3 bonus foods in one run", func(g *Game) bool {
+		return g.run.bonus >= 3
+	}},
+	{"combo5", "Combo Breaker", "reach a x5 combo", func(g *Game) bool {
+		return g.run.combo >= 5
+	}},
+	{"score50k", "High Roller", "score 50000 points in one run", func(g *Game) bool {
+		return g.sim.Points() >= 50000
+	}},
+	{"noleft", "Right Minded", "win without turning left", func(g *Game) bool {
+		return g.run.leftTurns == 0 && (g.sim.Won() || g.sim.Over() && g.options.TwoPlayer && g.sim.Winner() == 1)
+	}},
+}
+
+// runProgress is what a run did so far towards the achievements.
+type runProgress struct {
+	bonus     int
+	combo     int
+	leftTurns int
+	// direction is the direction of the last turn of player one
+	direction int
+}
+
+// toastLife is the number of frames an unlock is shown.
+const toastLife = 180
+
+// startAchievements starts tracking the progress of a new run.
+func (g *Game) startAchievements() {
+	g.run = runProgress{direction: g.sim.Snake().Direction()}
+}
+
+// trackAchievements counts the events of the run towards the
+// achievements.
+func (g *Game) trackAchievements(e game.Event) {
+	if e.Player != 0 {
+		return
+	}
+	switch e.Kind {
+	case game.EventTurn:
+		if e.Direction == (g.run.direction+3)%4 {
+			g.run.leftTurns++
+		}
+		g.run.direction = e.Direction
+	case game.EventEat:
+		if e.Food != nil && e.Food.Name == "bonus" {
+			g.run.bonus++
+		}
+		if e.Combo > g.run.combo {
+			g.run.combo = e.Combo
+		}
+	}
+}
+
+// checkAchievements unlocks the achievements the run reached. Runs on
+// autopilot, demos and replays unlock nothing.
+func (g *Game) checkAchievements() {
+	if g.demo || g.replay != nil || g.options.Autopilot != nil {
+		return
+	}
+	for _, a := range achievements {
+		if _, ok := g.unlocked[a.id]; ok || !a.reached(g) {
+			continue
+		}
+		g.unlocked[a.id] = time.Now()
+		g.toasts = append(g.toasts, a.name)
+		if g.options.AchievementsFile == "" {
+			continue
+		}
+		if err := saveAchievements(g.options.AchievementsFile, g.unlocked); err != nil {
+			log.Printf("could not save achievements: %v", err)
+		}
+	}
+}
+
+// loadAchievements reads the unlock times of the achievements from
+// path, keyed by their ids. A missing file has none unlocked.
+func loadAchievements(path string) (map[string]time.Time, error) {
+	unlocked := make(map[string]time.Time)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return unlocked, nil
+	}
+	if err != nil {
+		return unlocked, err
+	}
+	if err := json.Unmarshal(b, &unlocked); err != nil {
+		return make(map[string]time.Time), fmt.Errorf("%s: %v", path, err)
+	}
+	return unlocked, nil
+}
+
+func saveAchievements(path string, unlocked map[string]time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(unlocked, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// updateToasts counts down the unlock shown, moving on to the next one.
+func (g *Game) updateToasts() {
+	if len(g.toasts) == 0 {
+		return
+	}
+	g.toastShown++
+	if g.toastShown >= toastLife {
+		g.toasts = g.toasts[1:]
+		g.toastShown = 0
+	}
+}
+
+// drawToast shows the first achievement unlocked at the top of the
+// screen, fading in and out.
+func (g *Game) drawToast(canvas *ebiten.Image) {
+	if len(g.toasts) == 0 {
+		return
+	}
+	w := g.w
+	alpha := float64(g.toastShown) / popupLife
+	if left := float64(toastLife-g.toastShown) / popupLife; left < alpha {
+		alpha = left
+	}
+	if alpha > 1 {
+		alpha = 1
+	}
+	g.drawText(canvas, "achievement unlocked: "+g.toasts[0], w.cellW*2, g.hudRow(6), w.theme.Warning, alpha)
+}
+
+// updateAchievements leaves the achievements screen on Escape or Enter.
+func (g *Game) updateAchievements(back, enter bool) {
+	if back || enter {
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) drawAchievements(canvas *ebiten.Image) {
+	lines := []string{fmt.Sprintf("Achievements %d/%d", len(g.unlocked), len(achievements))}
+	for _, a := range achievements {
+		mark := "[ ]"
+		if _, ok := g.unlocked[a.id]; ok {
+			mark = "[x]"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s - %s", mark, a.name, a.desc))
+	}
+	lines = append(lines, "press Enter or Esc to return")
+	g.drawOverlay(canvas, lines...)
+}
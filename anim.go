@@ -0,0 +1,95 @@
+package snake
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// animation is an animated element of the interface, like a score
+// popup.
+type animation interface {
+	// update advances the animation by a frame and reports whether it
+	// is still running.
+	update() bool
+	draw(g *Game, canvas *ebiten.Image)
+}
+
+// animations runs the animated elements of the interface. They advance
+// every frame, not every tick, so they finish on the game over screen.
+type animations struct {
+	running []animation
+}
+
+func (a *animations) add(an animation) {
+	a.running = append(a.running, an)
+}
+
+// update advances the animations and drops the finished ones.
+func (a *animations) update() {
+	running := a.running[:0]
+	for _, an := range a.running {
+		if an.update() {
+			running = append(running, an)
+		}
+	}
+	// let go of the finished ones
+	for i := len(running); i < len(a.running); i++ {
+		a.running[i] = nil
+	}
+	a.running = running
+}
+
+func (a *animations) draw(g *Game, canvas *ebiten.Image) {
+	for _, an := range a.running {
+		an.draw(g, canvas)
+	}
+}
+
+func (a *animations) clear() {
+	a.running = nil
+}
+
+const popupLife = 30
+
+// popup is a floating text rising and fading at a board position.
+type popup struct {
+	text string
+	x, y float64
+	life int
+	// scale enlarges the text over the HUD scale
+	scale float64
+	clr   color.RGBA
+}
+
+func (p *popup) update() bool {
+	p.life--
+	p.y -= 0.5
+	return p.life > 0
+}
+
+func (p *popup) draw(g *Game, canvas *ebiten.Image) {
+	// popups living longer fade out in the last frames
+	alpha := math.Min(1, float64(p.life)/popupLife)
+	g.drawTextScaled(canvas, p.text, int(p.x-g.w.camX), int(p.y-g.w.camY), p.clr, alpha, p.scale)
+}
+
+// addPopup shows the points scored on a board cell. Points multiplied
+// by a combo show the multiplier and are drawn larger the higher it is.
+func (g *Game) addPopup(x, y int, points int64, combo int) {
+	px, py := g.w.cellToPixel(x, y)
+	text := strconv.FormatInt(points, 10)
+	if points > 0 {
+		text = "+" + text
+	}
+	p := &popup{text: text, x: px, y: py, life: popupLife, scale: 1, clr: g.w.theme.HUD}
+	if combo > 1 {
+		p.text = fmt.Sprintf("%s x%d", text, combo)
+		p.scale = 1 + float64(combo-1)/2
+		p.clr = g.w.theme.Warning
+	}
+	g.anims.add(p)
+}
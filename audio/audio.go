@@ -0,0 +1,137 @@
+// Package audio plays the music and sound effects of the game.
+package audio
+
+import (
+	"embed"
+	"io/ioutil"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/audio"
+	"github.com/hajimehoshi/ebiten/audio/wav"
+)
+
+const sampleRate = 44100
+
+// Sound is a sound effect.
+type Sound int
+
+const (
+	// Eat is played when the snake eats food.
+	Eat Sound = iota
+	// Die is played when the snake dies.
+	Die
+	// Turn is played when the snake changes its direction.
+	Turn
+	// Milestone is played when the score passes a milestone.
+	Milestone
+)
+
+var files = map[Sound]string{
+	Eat:       "assets/eat.wav",
+	Die:       "assets/die.wav",
+	Turn:      "assets/turn.wav",
+	Milestone: "assets/milestone.wav",
+}
+
+//go:embed assets/*.wav
+var assets embed.FS
+
+// Player plays the music and sound effects. A nil Player is silent.
+type Player struct {
+	context *audio.Context
+	// sounds holds the decoded PCM data of every sound
+	sounds map[Sound][]byte
+	music  *audio.Player
+	volume Volume
+	muted  bool
+}
+
+// NewPlayer creates the audio context, decodes the sound effects and
+// starts the music at volume v. There can only be one audio context per
+// process.
+func NewPlayer(v Volume) (*Player, error) {
+	context, err := audio.NewContext(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	p := &Player{
+		context: context,
+		sounds:  make(map[Sound][]byte, len(files)),
+	}
+	for s, name := range files {
+		b, err := assets.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := wav.Decode(context, audio.BytesReadSeekCloser(b))
+		if err != nil {
+			return nil, err
+		}
+		pcm, err := ioutil.ReadAll(stream)
+		if err != nil {
+			return nil, err
+		}
+		p.sounds[s] = pcm
+	}
+	pcm := chiptune()
+	loop := audio.NewInfiniteLoop(audio.BytesReadSeekCloser(pcm), int64(len(pcm)))
+	p.music, err = audio.NewPlayer(context, loop)
+	if err != nil {
+		return nil, err
+	}
+	p.SetVolume(v)
+	p.music.Play()
+	return p, nil
+}
+
+// Play starts playing s. Sounds may overlap.
+func (p *Player) Play(s Sound) {
+	if p == nil || p.muted {
+		return
+	}
+	player, err := audio.NewPlayerFromBytes(p.context, p.sounds[s])
+	if err != nil {
+		return
+	}
+	player.SetVolume(p.volume.Effects)
+	player.Play()
+}
+
+// Volume returns the current volume.
+func (p *Player) Volume() Volume {
+	if p == nil {
+		return Volume{}
+	}
+	return p.volume
+}
+
+// SetVolume changes the volume of the music right away and of the
+// sound effects played from now on. Levels are rounded to VolumeStep
+// and limited to the range 0 to 1.
+func (p *Player) SetVolume(v Volume) {
+	if p == nil {
+		return
+	}
+	v.Music = math.Round(v.Music/VolumeStep) * VolumeStep
+	v.Effects = math.Round(v.Effects/VolumeStep) * VolumeStep
+	p.volume = v.clamp()
+	p.music.SetVolume(p.volume.Music)
+}
+
+// Muted reports whether the sound is muted.
+func (p *Player) Muted() bool {
+	return p == nil || p.muted
+}
+
+// ToggleMute mutes or unmutes the sound.
+func (p *Player) ToggleMute() {
+	if p == nil {
+		return
+	}
+	p.muted = !p.muted
+	if p.muted {
+		p.music.Pause()
+	} else {
+		p.music.Play()
+	}
+}
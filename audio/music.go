@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"math"
+)
+
+const (
+	// tempo of the music in beats per minute
+	tempo = 140
+	// steps per beat
+	stepsPerBeat = 4
+)
+
+// melody and bass are the notes of the music loop, one per step, as
+// semitones above A2. -1 is a rest.
+var (
+	melody = []int{
+		12, -1, 15, -1, 19, -1, 15, -1, 24, -1, 22, 19, 17, -1, 15, -1,
+		12, -1, 15, -1, 19, -1, 22, -1, 20, -1, 19, 17, 15, -1, -1, -1,
+		17, -1, 20, -1, 24, -1, 20, -1, 27, -1, 26, 24, 22, -1, 20, -1,
+		19, -1, 17, -1, 15, -1, 14, -1, 12, -1, -1, -1, 14, -1, 15, -1,
+	}
+	bass = []int{
+		0, -1, 0, 12, 0, -1, 0, 12, 0, -1, 0, 12, 0, -1, 0, 12,
+		-4, -1, -4, 8, -4, -1, -4, 8, -2, -1, -2, 10, -2, -1, -2, 10,
+		5, -1, 5, 17, 5, -1, 5, 17, 3, -1, 3, 15, 3, -1, 3, 15,
+		7, -1, 7, 19, 7, -1, 7, 19, 7, -1, 7, 19, 7, -1, 7, 19,
+	}
+)
+
+// chiptune renders the music loop as 16 bit stereo PCM: a square wave
+// melody over a triangle wave bass.
+func chiptune() []byte {
+	step := sampleRate * 60 / tempo / stepsPerBeat
+	pcm := make([]byte, 0, len(melody)*step*4)
+	for i := range melody {
+		for j := 0; j < step; j++ {
+			// short attack and a decay over the step
+			env := math.Min(1, float64(j)/64) * (1 - 0.6*float64(j)/float64(step))
+			t := float64(i*step+j) / sampleRate
+			v := 0.12*env*square(melody[i], t) + 0.18*env*triangle(bass[i], t)
+			s := int16(v * math.MaxInt16)
+			pcm = append(pcm, byte(s), byte(s>>8), byte(s), byte(s>>8))
+		}
+	}
+	return pcm
+}
+
+// frequency returns the frequency of the note n semitones above A2.
+func frequency(n int) float64 {
+	return 110 * math.Pow(2, float64(n)/12)
+}
+
+func square(n int, t float64) float64 {
+	if n < 0 {
+		return 0
+	}
+	if math.Mod(t*frequency(n), 1) < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func triangle(n int, t float64) float64 {
+	if n < 0 {
+		return 0
+	}
+	return 4*math.Abs(math.Mod(t*frequency(n), 1)-0.5) - 1
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// VolumeStep is the amount a volume changes per key press.
+const VolumeStep = 0.1
+
+// Volume are the volume levels of music and sound effects, each from 0
+// to 1.
+type Volume struct {
+	Music   float64 `json:"music"`
+	Effects float64 `json:"effects"`
+}
+
+// DefaultVolume is the volume before the player changed it.
+var DefaultVolume = Volume{Music: 0.5, Effects: 1}
+
+// DefaultVolumeFile returns the path of the volume settings in the
+// user's config directory.
+func DefaultVolumeFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "volume.json")
+}
+
+// LoadVolume reads the volume settings from path. A missing file is the
+// default volume.
+func LoadVolume(path string) (Volume, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultVolume, nil
+	}
+	if err != nil {
+		return DefaultVolume, err
+	}
+	v := DefaultVolume
+	if err := json.Unmarshal(b, &v); err != nil {
+		return DefaultVolume, err
+	}
+	return v.clamp(), nil
+}
+
+// Save writes the volume settings to path.
+func (v Volume) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// clamp limits both levels to the range 0 to 1.
+func (v Volume) clamp() Volume {
+	v.Music = math.Max(0, math.Min(1, v.Music))
+	v.Effects = math.Max(0, math.Min(1, v.Effects))
+	return v
+}
@@ -0,0 +1,123 @@
+// Package bot defines the controllers steering a snake in place of a
+// player, and the snapshot of the game they decide on.
+package bot
+
+import (
+	"sort"
+	"sync"
+)
+
+// Direction is a direction a snake moves in.
+type Direction int
+
+// The directions in the order the game numbers them.
+const (
+	Right Direction = iota
+	Down
+	Left
+	Up
+)
+
+// Cell is a cell of the board, counted from the top left corner.
+type Cell struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Snake is a snake on the board.
+type Snake struct {
+	// Body are the cells of the snake from the head to the tail.
+	Body      []Cell    `json:"body"`
+	Direction Direction `json:"direction"`
+}
+
+// Head returns the cell of the head.
+func (s Snake) Head() Cell {
+	return s.Body[0]
+}
+
+// GameState is a snapshot of the board taken before a step.
+type GameState struct {
+	// Width and Height are the number of cells of the board.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Walls is set if the board edges are solid. Otherwise snakes wrap
+	// around them.
+	Walls     bool    `json:"walls"`
+	Obstacles []Cell  `json:"obstacles"`
+	You       Snake   `json:"you"`
+	Others    []Snake `json:"others"`
+	Food      []Cell  `json:"food"`
+	// Hazards are cells a snake should not move onto, like poison.
+	Hazards []Cell `json:"hazards"`
+}
+
+// Neighbor returns the cell next to c in direction d, wrapping around
+// the board edges. ok is false if the step crosses a solid edge.
+func (s *GameState) Neighbor(c Cell, d Direction) (n Cell, ok bool) {
+	n = c
+	switch d {
+	case Right:
+		n.X++
+	case Down:
+		n.Y++
+	case Left:
+		n.X--
+	case Up:
+		n.Y--
+	}
+	if n.X >= 0 && n.X < s.Width && n.Y >= 0 && n.Y < s.Height {
+		return n, true
+	}
+	n.X = (n.X + s.Width) % s.Width
+	n.Y = (n.Y + s.Height) % s.Height
+	return n, !s.Walls
+}
+
+// Bot steers a snake.
+type Bot interface {
+	// NextMove returns the direction to move You in on the next step.
+	// Reversing into the body is ignored.
+	NextMove(state GameState) Direction
+}
+
+// Func adapts a function to a Bot.
+type Func func(state GameState) Direction
+
+// NextMove calls f(state).
+func (f Func) NextMove(state GameState) Direction {
+	return f(state)
+}
+
+var (
+	mu   sync.Mutex
+	bots = map[string]Bot{}
+)
+
+// Register makes b available under name, replacing a bot registered
+// before under the same name.
+func Register(name string, b Bot) {
+	mu.Lock()
+	defer mu.Unlock()
+	bots[name] = b
+}
+
+// Lookup returns the bot registered under name.
+func Lookup(name string) (Bot, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	b, ok := bots[name]
+	return b, ok
+}
+
+// Names returns the names of the registered bots.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(bots))
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,120 @@
+package bot
+
+func init() {
+	Register("greedy", Greedy{})
+}
+
+// Greedy follows the shortest path to the nearest food. If there is no
+// path, or the path leads into a pocket too small for its body, it
+// heads for the neighbor with the most room instead.
+type Greedy struct{}
+
+// NextMove implements Bot.
+func (Greedy) NextMove(s GameState) Direction {
+	blocked := s.blocked()
+	food := make([]bool, len(blocked))
+	for _, c := range s.Food {
+		if s.inside(c) {
+			food[c.Y*s.Width+c.X] = true
+		}
+	}
+	h := s.You.Head()
+
+	// first is the direction of the first step on the path to each
+	// cell, -1 for cells not seen yet
+	first := make([]Direction, len(blocked))
+	for i := range first {
+		first[i] = -1
+	}
+	next := Direction(-1)
+	queue := []Cell{h}
+	for len(queue) > 0 && next == -1 {
+		c := queue[0]
+		queue = queue[1:]
+		for d := Right; d <= Up; d++ {
+			n, ok := s.Neighbor(c, d)
+			i := n.Y*s.Width + n.X
+			if !ok || blocked[i] || first[i] != -1 {
+				continue
+			}
+			first[i] = d
+			if c != h {
+				first[i] = first[c.Y*s.Width+c.X]
+			}
+			if food[i] {
+				next = first[i]
+				break
+			}
+			queue = append(queue, n)
+		}
+	}
+
+	if next != -1 {
+		n, _ := s.Neighbor(h, next)
+		if s.room(blocked, n) >= len(s.You.Body) {
+			return next
+		}
+	}
+	best, most := s.You.Direction, 0
+	for d := Right; d <= Up; d++ {
+		n, ok := s.Neighbor(h, d)
+		if !ok {
+			continue
+		}
+		if r := s.room(blocked, n); r > most {
+			best, most = d, r
+		}
+	}
+	return best
+}
+
+// blocked returns for every cell, indexed by y*Width+x, whether a snake
+// must not move there.
+func (s *GameState) blocked() []bool {
+	blocked := make([]bool, s.Width*s.Height)
+	mark := func(cells []Cell) {
+		for _, c := range cells {
+			if s.inside(c) {
+				blocked[c.Y*s.Width+c.X] = true
+			}
+		}
+	}
+	mark(s.You.Body)
+	for _, o := range s.Others {
+		mark(o.Body)
+	}
+	mark(s.Obstacles)
+	mark(s.Hazards)
+	return blocked
+}
+
+// inside reports whether c is on the board. Cells off the board, like
+// food without a free cell left, are ignored.
+func (s *GameState) inside(c Cell) bool {
+	return c.X >= 0 && c.X < s.Width && c.Y >= 0 && c.Y < s.Height
+}
+
+// room counts the free cells reachable from c.
+func (s *GameState) room(blocked []bool, c Cell) int {
+	seen := append([]bool(nil), blocked...)
+	if seen[c.Y*s.Width+c.X] {
+		return 0
+	}
+	seen[c.Y*s.Width+c.X] = true
+	n := 0
+	queue := []Cell{c}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		n++
+		for d := Right; d <= Up; d++ {
+			nc, ok := s.Neighbor(c, d)
+			if !ok || seen[nc.Y*s.Width+nc.X] {
+				continue
+			}
+			seen[nc.Y*s.Width+nc.X] = true
+			queue = append(queue, nc)
+		}
+	}
+	return n
+}
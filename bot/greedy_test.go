@@ -0,0 +1,16 @@
+package bot
+
+import "testing"
+
+func TestGreedyOffBoard(t *testing.T) {
+	s := GameState{
+		Width:   3,
+		Height:  3,
+		You:     Snake{Body: []Cell{{1, 1}, {0, 1}}, Direction: Right},
+		Food:    []Cell{{-1, -1}},
+		Hazards: []Cell{{-1, -1}, {3, 0}},
+	}
+	if d := (Greedy{}).NextMove(s); d < Right || d > Up {
+		t.Errorf("NextMove() = %d, want a direction", d)
+	}
+}
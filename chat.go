@@ -0,0 +1,135 @@
+package snake
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/netplay"
+)
+
+const (
+	// chatLines is the number of chat messages shown at most.
+	chatLines = 5
+	// chatLife is the number of frames a chat message is shown.
+	chatLife = 8 * 60
+	// emoteLife is the number of frames an emote floats above a snake.
+	emoteLife = 2 * 60
+)
+
+// emoteKeys are the keys sending the emotes during a match.
+var emoteKeys = []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5, ebiten.Key6}
+
+// chatLine is a chat message shown for life more frames.
+type chatLine struct {
+	text string
+	life int
+}
+
+// updateChat handles the chat of an online match: Enter opens it, Enter
+// sends the message typed and Escape closes it. Players send emotes
+// with the number keys. updateChat reports whether it took the keys.
+func (g *Game) updateChat(back, enter bool) bool {
+	if !g.chatOpen {
+		if enter {
+			g.chatOpen, g.chatText = true, ""
+			return true
+		}
+		if g.netPlayer >= 0 {
+			for i, k := range emoteKeys {
+				if inpututil.IsKeyJustPressed(k) && i < len(netplay.Emotes) {
+					g.send(netplay.Message{Type: netplay.MsgEmote, Emote: i})
+				}
+			}
+		}
+		return false
+	}
+	for _, r := range ebiten.InputChars() {
+		if utf8.RuneCountInString(g.chatText) < netplay.MaxChat {
+			g.chatText += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && g.chatText != "" {
+		runes := []rune(g.chatText)
+		g.chatText = string(runes[:len(runes)-1])
+	}
+	switch {
+	case enter:
+		if g.chatText != "" {
+			g.send(netplay.Message{Type: netplay.MsgChat, Text: g.chatText})
+		}
+		g.chatOpen = false
+	case back:
+		g.chatOpen = false
+	}
+	return true
+}
+
+// sender names the client who sent m.
+func (g *Game) sender(m netplay.Message) string {
+	switch {
+	case m.Player < 0:
+		return "spectator"
+	case m.Player == g.netPlayer:
+		return "you"
+	}
+	return fmt.Sprintf("P%d", m.Player+1)
+}
+
+// receiveChat shows a chat message or emote. Emotes float above the
+// snake of the player who sent them.
+func (g *Game) receiveChat(m netplay.Message) {
+	text := m.Text
+	if m.Type == netplay.MsgEmote {
+		if m.Emote < 0 || m.Emote >= len(netplay.Emotes) {
+			return
+		}
+		text = netplay.Emotes[m.Emote]
+		if m.Player >= 0 && g.state == StatePlaying {
+			if s, _ := g.shownSnake(m.Player); s != nil {
+				h := s.Head()
+				px, py := g.w.cellToPixel(h.X, h.Y)
+				clr := g.w.theme.Snake
+				if m.Player == 1 {
+					clr = g.w.theme.Rival
+				}
+				g.anims.add(&popup{text: text, x: px, y: py, life: emoteLife, scale: 1.5, clr: clr})
+			}
+		}
+	}
+	g.chatLog = append(g.chatLog, chatLine{fmt.Sprintf("%s: %s", g.sender(m), text), chatLife})
+	if len(g.chatLog) > chatLines {
+		g.chatLog = g.chatLog[len(g.chatLog)-chatLines:]
+	}
+}
+
+// updateChatLog fades out the chat messages.
+func (g *Game) updateChatLog() {
+	log := g.chatLog[:0]
+	for _, l := range g.chatLog {
+		if l.life--; l.life > 0 {
+			log = append(log, l)
+		}
+	}
+	g.chatLog = log
+}
+
+// drawChat draws the chat messages and the one typed during a match.
+func (g *Game) drawChat(canvas *ebiten.Image) {
+	if g.net == nil || g.state != StatePlaying {
+		return
+	}
+	w := g.w
+	lh := g.face.Metrics().Height.Ceil()
+	x, y := w.cellW*2, g.hudRow(8)
+	for _, l := range g.chatLog {
+		alpha := math.Min(1, float64(l.life)/popupLife)
+		g.drawText(canvas, l.text, x, y, w.theme.HUD, alpha)
+		y += lh
+	}
+	if g.chatOpen {
+		g.drawText(canvas, "say: "+g.chatText+"_", x, y, w.theme.Warning, 1)
+	}
+}
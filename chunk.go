@@ -0,0 +1,118 @@
+package snake
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// chunkCells is the width and height of a chunk of the board in cells.
+const chunkCells = 16
+
+// chunkSignature tells whether the obstacles changed since the chunks
+// were drawn. Obstacles are only ever added or replaced all at once for
+// a new level, so their number, the first and the last one are enough.
+type chunkSignature struct {
+	n           int
+	first, last game.Cell
+}
+
+func signature(cells []game.Cell) chunkSignature {
+	if len(cells) == 0 {
+		return chunkSignature{}
+	}
+	return chunkSignature{len(cells), cells[0], cells[len(cells)-1]}
+}
+
+// chunkCount returns the number of chunks across and down the board,
+// including the borders.
+func (w *world) chunkCount() (int, int) {
+	return (w.cellsX + 3 + chunkCells - 1) / chunkCells, (w.cellsY + 3 + chunkCells - 1) / chunkCells
+}
+
+// drawObstacles draws the borders of the board and the obstacles in
+// cells. They are drawn into chunks of the board once these come into
+// view, and only the chunks on the screen are drawn, so boards much
+// larger than the screen take no longer to draw than small ones.
+func (w *world) drawObstacles(canvas *ebiten.Image, cells []game.Cell) {
+	if sig := signature(cells); w.chunks == nil || sig != w.chunkSig {
+		w.resetChunks(cells)
+		w.chunkSig = sig
+	}
+	nx, ny := w.chunkCount()
+	cw, ch := float64(chunkCells*w.cellW), float64(chunkCells*w.cellH)
+	x0, x1 := visibleChunks(w.camX, cw, w.screenW, nx)
+	y0, y1 := visibleChunks(w.camY, ch, w.screenH, ny)
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			// drawing a new chunk uses the options too
+			img := w.chunk(cx, cy)
+			w.opts.GeoM.Reset()
+			w.opts.GeoM.Translate(float64(cx)*cw-w.camX, float64(cy)*ch-w.camY)
+			canvas.DrawImage(img, &w.opts)
+		}
+	}
+}
+
+// visibleChunks returns the first and the last of n chunks of size
+// pixels on a screen of screen pixels scrolled by cam.
+func visibleChunks(cam, size float64, screen, n int) (int, int) {
+	first := int(math.Floor(cam / size))
+	last := int(math.Floor((cam + float64(screen)) / size))
+	if first < 0 {
+		first = 0
+	}
+	if last >= n {
+		last = n - 1
+	}
+	return first, last
+}
+
+// resetChunks drops the chunks drawn and sorts cells into the chunks
+// they are on.
+func (w *world) resetChunks(cells []game.Cell) {
+	for _, img := range w.chunks {
+		img.Dispose()
+	}
+	w.chunks = make(map[int]*ebiten.Image)
+	w.chunkWalls = make(map[int][]game.Cell)
+	nx, _ := w.chunkCount()
+	for _, c := range cells {
+		// the border takes column and row 0 of the first chunk
+		i := (c.Y+1)/chunkCells*nx + (c.X+1)/chunkCells
+		w.chunkWalls[i] = append(w.chunkWalls[i], c)
+	}
+}
+
+// chunk returns the image of the chunk in column cx and row cy, drawing
+// its borders and obstacles first if it was not drawn yet.
+func (w *world) chunk(cx, cy int) *ebiten.Image {
+	nx, _ := w.chunkCount()
+	i := cy*nx + cx
+	if img, ok := w.chunks[i]; ok {
+		return img
+	}
+	img, _ := ebiten.NewImage(chunkCells*w.cellW, chunkCells*w.cellH, w.filter)
+	ox, oy := float64(cx*chunkCells*w.cellW), float64(cy*chunkCells*w.cellH)
+	tile := func(t *ebiten.Image, x, y int) {
+		px, py := w.cellToPixel(x, y)
+		w.opts.GeoM.Reset()
+		w.opts.GeoM.Translate(px-ox, py-oy)
+		img.DrawImage(t, &w.opts)
+	}
+	// the border runs around the board on the cells -1 and one past
+	// the last
+	for y := cy*chunkCells - 1; y < (cy+1)*chunkCells-1 && y <= w.cellsY+1; y++ {
+		for x := cx*chunkCells - 1; x < (cx+1)*chunkCells-1 && x <= w.cellsX+1; x++ {
+			if x == -1 || y == -1 || x == w.cellsX+1 || y == w.cellsY+1 {
+				tile(w.borderTile, x, y)
+			}
+		}
+	}
+	for _, c := range w.chunkWalls[i] {
+		tile(w.obstacleTile, c.X, c.Y)
+	}
+	w.chunks[i] = img
+	return img
+}
@@ -0,0 +1,82 @@
+// Command snake-server runs an online leaderboard for snake, kept in a
+// SQLite database, and head-to-head matches over WebSocket.
+//
+// Scores are posted as JSON to /scores and only accepted if their run
+// re-simulated ends with the score. The best scores of a mode are
+// fetched with a GET of /scores?mode=MODE&limit=N. /modes lists the
+// modes scores were posted in. Start snake with -leaderboard
+// http://HOST/scores to use it.
+//
+// Players connecting to /play with snake -connect ws://HOST/play meet
+// in rooms for matches of two on a shared arena simulated by the
+// server. -badwords masks the words listed in a file, one per line, in
+// their chat.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/wongak/snake/game"
+	"github.com/wongak/snake/netplay"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "`address` to listen on")
+	path := flag.String("db", "leaderboard.db", "SQLite database `file`, created if missing")
+	walls := flag.Bool("walls", false, "end the matches at the board edges instead of wrapping around")
+	tps := flag.Int("tps", 60, "ticks per second the matches advance by")
+	badWords := flag.String("badwords", "", "`file` of words to mask in the chat, one per line")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *tps < 1 {
+		log.Fatalf("invalid -tps %d", *tps)
+	}
+	rules := game.Rules{InitialLength: 3, Walls: *walls}
+	play := netplay.NewServer(rules, netplay.DefaultSettings, *tps)
+	if *badWords != "" {
+		words, err := readWords(*badWords)
+		if err != nil {
+			log.Fatalf("could not read the bad words: %v", err)
+		}
+		play.Filter = netplay.WordFilter(words)
+	}
+
+	s, err := openStore(*path)
+	if err != nil {
+		log.Fatalf("could not open the database: %v", err)
+	}
+	defer s.Close()
+	log.Printf("serving the leaderboard on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newServer(s, play)))
+}
+
+// readWords reads the lines of the file at path.
+func readWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		words = append(words, sc.Text())
+	}
+	return words, sc.Err()
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: %s [flags]
+
+Serves an online leaderboard for snake -leaderboard http://HOST/scores
+and matches for snake -connect ws://HOST/play.
+
+`, os.Args[0])
+	flag.PrintDefaults()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wongak/snake/leaderboard"
+)
+
+const (
+	// defaultLimit and maxLimit are the number of entries listed without
+	// a limit and at most.
+	defaultLimit = 20
+	maxLimit     = 100
+	// maxName and maxMode are the longest names and modes accepted, in
+	// characters.
+	maxName = 16
+	maxMode = 32
+	// maxBody is the largest score posted accepted, in bytes, with the
+	// turns of a long run.
+	maxBody = 1 << 20
+	// queueWait is how long a score posted waits for its turn to be
+	// verified before it is turned away.
+	queueWait = 10 * time.Second
+)
+
+// server answers the requests of the leaderboard API.
+type server struct {
+	s   *store
+	mux *http.ServeMux
+	// verifying holds a token for every run being verified, one per CPU
+	// at most
+	verifying chan struct{}
+}
+
+func newServer(s *store, play http.Handler) *server {
+	srv := &server{s: s, mux: http.NewServeMux(), verifying: make(chan struct{}, runtime.NumCPU())}
+	srv.mux.HandleFunc("/scores", srv.scores)
+	srv.mux.HandleFunc("/modes", srv.modes)
+	srv.mux.Handle("/play", play)
+	return srv
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the browser build posts from the page it was loaded from
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	srv.mux.ServeHTTP(w, r)
+}
+
+// scores lists the best scores of a mode on GET and adds a score on
+// POST.
+func (srv *server) scores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		srv.top(w, r)
+	case http.MethodPost:
+		srv.submit(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (srv *server) top(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = "classic"
+	}
+	limit := defaultLimit
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	entries, err := srv.s.top(mode, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (srv *server) submit(w http.ResponseWriter, r *http.Request) {
+	var e leaderboard.Entry
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&e); err != nil {
+		http.Error(w, "invalid score: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validate(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := srv.verify(r, e); err == errBusy {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.Printf("rejected score %d of %s: %v", e.Score, e.Name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	e.Run = nil
+	e, err := srv.s.add(e)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// errBusy is returned by verify if the run could not be verified in
+// time because of the runs of other requests.
+var errBusy = errors.New("too many scores to verify, try again later")
+
+// verify verifies the run of e once one of the runs verified before
+// finished, see leaderboard.Verify. It gives up with errBusy after
+// queueWait or once the request was cancelled.
+func (srv *server) verify(r *http.Request, e leaderboard.Entry) error {
+	t := time.NewTimer(queueWait)
+	defer t.Stop()
+	select {
+	case srv.verifying <- struct{}{}:
+	case <-t.C:
+		return errBusy
+	case <-r.Context().Done():
+		return errBusy
+	}
+	defer func() { <-srv.verifying }()
+	return leaderboard.Verify(e)
+}
+
+// modes lists the modes scores were posted in.
+func (srv *server) modes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	boards, err := srv.s.boards()
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, boards)
+}
+
+// validate checks the score e posted, trimming the name.
+func validate(e *leaderboard.Entry) error {
+	e.Name = strings.TrimSpace(e.Name)
+	switch {
+	case e.Name == "" || utf8.RuneCountInString(e.Name) > maxName:
+		return fmt.Errorf("the name must have 1 to %d characters", maxName)
+	case e.Score < 0:
+		return fmt.Errorf("negative score")
+	case e.Mode == "" || len(e.Mode) > maxMode:
+		return fmt.Errorf("the mode must have 1 to %d characters", maxMode)
+	}
+	for _, r := range e.Mode {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return fmt.Errorf("invalid mode %q", e.Mode)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("could not write the answer: %v", err)
+	}
+}
+
+func internalError(w http.ResponseWriter, err error) {
+	log.Printf("database error: %v", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+	"github.com/wongak/snake/leaderboard"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS scores (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	score INTEGER NOT NULL,
+	mode TEXT NOT NULL,
+	date TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS scores_by_mode ON scores (mode, score DESC);
+`
+
+// store keeps the scores of all modes in a SQLite database.
+type store struct {
+	db *sql.DB
+}
+
+// openStore opens the database at path, creating the tables if they are
+// missing.
+func openStore(path string) (*store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer only
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *store) Close() error {
+	return s.db.Close()
+}
+
+// add stores e, dated now.
+func (s *store) add(e leaderboard.Entry) (leaderboard.Entry, error) {
+	e.Date = time.Now().UTC()
+	_, err := s.db.Exec(`INSERT INTO scores (name, score, mode, date) VALUES (?, ?, ?, ?)`,
+		e.Name, e.Score, e.Mode, e.Date)
+	return e, err
+}
+
+// top returns the best n entries of mode, the earlier of equal scores
+// first.
+func (s *store) top(mode string, n int) ([]leaderboard.Entry, error) {
+	rows, err := s.db.Query(`SELECT name, score, mode, date FROM scores
+		WHERE mode = ? ORDER BY score DESC, date ASC LIMIT ?`, mode, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := []leaderboard.Entry{}
+	for rows.Next() {
+		var e leaderboard.Entry
+		if err := rows.Scan(&e.Name, &e.Score, &e.Mode, &e.Date); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// board is a mode and the number of scores posted in it.
+type board struct {
+	Mode   string `json:"mode"`
+	Scores int    `json:"scores"`
+}
+
+// boards returns the modes scores were posted in.
+func (s *store) boards() ([]board, error) {
+	rows, err := s.db.Query(`SELECT mode, COUNT(*) FROM scores GROUP BY mode ORDER BY mode`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	boards := []board{}
+	for rows.Next() {
+		var b board
+		if err := rows.Scan(&b.Mode, &b.Scores); err != nil {
+			return nil, err
+		}
+		boards = append(boards, b)
+	}
+	return boards, rows.Err()
+}
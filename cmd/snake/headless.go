@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+// headlessStats are the stats of a game played headless, written as a
+// JSON line.
+type headlessStats struct {
+	Game      int   `json:"game"`
+	Seed      int64 `json:"seed"`
+	Score     int64 `json:"score"`
+	Length    int   `json:"length"`
+	MaxLength int   `json:"max_length"`
+	Eaten     int   `json:"food_eaten"`
+	Ticks     int64 `json:"ticks"`
+	// Capped is set if the game was stopped after the tick limit
+	Capped bool `json:"capped,omitempty"`
+}
+
+// runHeadless plays n games without a window as fast as possible, with
+// the autopilot of rules steering, and writes the stats of every game
+// to w. Games are stopped after maxTicks ticks, 0 does not stop them.
+// With a seed the games are seeded with seed, seed+1 and so on.
+func runHeadless(w io.Writer, rules game.Rules, seed int64, n int, maxTicks int64) error {
+	enc := json.NewEncoder(w)
+	for i := 0; i < n; i++ {
+		s := seed + int64(i)
+		if seed == 0 {
+			s = time.Now().UnixNano()
+		}
+		sim := game.New(rules, s)
+		for !sim.Over() && (maxTicks == 0 || sim.Frame() < maxTicks) {
+			if sim.LevelComplete() {
+				sim.NextLevel()
+			}
+			sim.Tick()
+		}
+		err := enc.Encode(headlessStats{
+			Game:      i + 1,
+			Seed:      s,
+			Score:     sim.Points(),
+			Length:    sim.Snake().Len(),
+			MaxLength: sim.MaxLength(),
+			Eaten:     sim.Eaten(),
+			Ticks:     sim.Frame(),
+			Capped:    !sim.Over(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
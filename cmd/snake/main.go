@@ -0,0 +1,281 @@
+// Command snake runs the snake game in a window, in a terminal with
+// -tui or without any display with -headless.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake"
+	"github.com/wongak/snake/bot"
+	"github.com/wongak/snake/config"
+	"github.com/wongak/snake/game"
+	"github.com/wongak/snake/tui"
+)
+
+const (
+	title = "snake"
+)
+
+func main() {
+	o := snake.DefaultOptions()
+	if path := config.DefaultPath(); path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			log.Printf("could not load config: %v", err)
+		}
+		if err := cfg.Apply(&o); err != nil {
+			log.Printf("invalid config %s: %v", path, err)
+		}
+		if err == nil {
+			// keep a broken config file for the player to fix
+			o.OnKeymapChange = func(keys snake.Keymap) {
+				cfg.SetKeymap(keys)
+				if err := cfg.Save(path); err != nil {
+					log.Printf("could not save controls: %v", err)
+				}
+			}
+			o.OnOptionsChange = func(o snake.Options) {
+				cfg.SetOptions(o)
+				if err := cfg.Save(path); err != nil {
+					log.Printf("could not save settings: %v", err)
+				}
+			}
+		}
+	}
+	flag.IntVar(&o.Width, "width", o.Width, "window width in pixels")
+	flag.IntVar(&o.Height, "height", o.Height, "window height in pixels")
+	flag.BoolVar(&o.Fullscreen, "fullscreen", o.Fullscreen, "run in fullscreen mode, F11 toggles it")
+	flag.IntVar(&o.CellsX, "cellsx", o.CellsX, "number of horizontal cells")
+	flag.IntVar(&o.CellsY, "cellsy", o.CellsY, "number of vertical cells")
+	cells := flag.String("cells", "", "board size in cells as `COLSxROWS`, overrides -cellsx and -cellsy")
+	flag.IntVar(&o.CellSize, "cellsize", o.CellSize, "cell size in pixels, 0 fits the board into the window or scrolls boards too large for it")
+	flag.BoolVar(&o.Effects, "effects", o.Effects, "enable visual effects")
+	flag.Float64Var(&o.Speed, "speed", o.Speed, "initial number of ticks between two steps, lower is faster")
+	flag.IntVar(&o.TickRate, "tps", snake.DefaultTickRate, "ticks per second the game advances by, higher is faster")
+	flag.BoolVar(&o.Chaos, "chaos", o.Chaos, "periodically reverse the controls")
+	flag.BoolVar(&o.Walls, "walls", o.Walls, "end the game at the board edges instead of wrapping around")
+	flag.BoolVar(&o.TwoPlayer, "twoplayer", o.TwoPlayer, "two players on one keyboard, arrows and WASD")
+	flag.BoolVar(&o.LongerWins, "longerwins", o.LongerWins, "in two-player games the longer snake survives a head-on crash instead of both")
+	flag.BoolVar(&o.Coop, "coop", o.Coop, "two players on one keyboard playing together with shared lives")
+	flag.BoolVar(&o.AI, "ai", o.AI, "add a computer snake competing for the food")
+	botName := flag.String("bot", "greedy", "bot steering the computer snake, one of "+strings.Join(bot.Names(), ", "))
+	autopilot := flag.String("autopilot", "", "bot steering the player's snake, one of "+strings.Join(bot.Names(), ", "))
+	flag.BoolVar(&o.Tron, "tron", o.Tron, "light-cycle mode, the trail stays and the score is the time survived")
+	flag.BoolVar(&o.Shrink, "shrink", o.Shrink, "the walls close in on the arena every 30 seconds")
+	flag.IntVar(&o.Gauntlet, "gauntlet", o.Gauntlet, "spawn three obstacles every time the score rose by this many `points`, 0 spawns none")
+	flag.BoolVar(&o.Campaign, "campaign", o.Campaign, "play through the built-in campaign levels")
+	level := flag.String("level", "", "arena `path` of a level file, or one of the built-in levels "+strings.Join(game.BuiltinLevels(), ", "))
+	flag.BoolVar(&o.Powerups, "powerups", o.Powerups, "spawn power-ups with temporary effects")
+	flag.BoolVar(&o.Poison, "poison", o.Poison, "spawn poison which shrinks the snake and costs points")
+	flag.IntVar(&o.Obstacles, "obstacles", o.Obstacles, "number of impassable blocks placed on the board")
+	flag.BoolVar(&o.WrapGrace, "wrapgrace", o.WrapGrace, "survive landing on the own body when wrapping around the edge")
+	filterName := "nearest"
+	if o.Filter == ebiten.FilterLinear {
+		filterName = "linear"
+	}
+	filter := flag.String("filter", filterName, "tile filter, nearest or linear")
+	flag.IntVar(&o.Harvest, "harvest", o.Harvest, "spawn food in waves of this many items, 0 disables")
+	flag.IntVar(&o.GrowPerFood, "grow-per-food", o.GrowPerFood, "segments grown per food, 0 grows faster with higher points")
+	flag.BoolVar(&o.Sound, "sound", o.Sound, "play music and sound effects, M mutes them, + and - change the volume")
+	flag.BoolVar(&o.TouchButtons, "touchbuttons", o.TouchButtons, "show on-screen arrow buttons for touch screens")
+	flag.Float64Var(&o.DeadZone, "deadzone", o.DeadZone, "ignored share of the gamepad stick range, from 0 to 1")
+	flag.BoolVar(&o.CRT, "crt", o.CRT, "draw the screen with a retro CRT effect")
+	flag.BoolVar(&o.Debug, "debug", o.Debug, "enable the debug overlay, F3 shows it and F4 the grid")
+	flag.BoolVar(&o.IntegerScale, "integer", o.IntegerScale, "scale the screen by whole factors only, with bars around it")
+	flag.BoolVar(&o.Forbidden, "forbidden", o.Forbidden, "place a forbidden food which ends the game when eaten")
+	flag.BoolVar(&o.ReachableFirstFood, "reachable-first-food", o.ReachableFirstFood, "place the first food where the snake can reach it")
+	flag.BoolVar(&o.Assist, "assist", o.Assist, "place all food where the snake can reach it")
+	flag.BoolVar(&o.Minimap, "minimap", o.Minimap, "show a minimap of the whole board")
+	flag.StringVar(&o.Background, "background", o.Background, "PNG image drawn behind the board")
+	flag.BoolVar(&o.SlowMotion, "slowmotion", o.SlowMotion, "slow down right before a collision")
+	flag.BoolVar(&o.Segmented, "segmented", o.Segmented, "draw the snake with gaps between the segments")
+	flag.BoolVar(&o.Combo, "combo", o.Combo, "multiply the points of food eaten in quick succession, up to x5")
+	flag.BoolVar(&o.Shapes, "shapes", o.Shapes, "tell food, poison and snakes apart by shape as well as by color")
+	flag.BoolVar(&o.Smooth, "smooth", o.Smooth, "draw the snake gliding between cells, -smooth=false lets it jump from cell to cell")
+	flag.StringVar(&o.HighScoreFile, "highscores", o.HighScoreFile, "high score table file, empty keeps scores in memory")
+	flag.StringVar(&o.Leaderboard, "leaderboard", o.Leaderboard, "`URL` of an online leaderboard the scores are posted to")
+	flag.StringVar(&o.Connect, "connect", o.Connect, "play online against another player on the snake-server at this WebSocket `URL`, like ws://localhost:8080/play")
+	flag.BoolVar(&o.Ghost, "ghost", o.Ghost, "race against a ghost of your best run, kept next to the high scores")
+	flag.Int64Var(&o.StreakScore, "streakscore", o.StreakScore, "score a run needs to extend the streak of runs in a row shown on the menu")
+	flag.DurationVar(&o.IdlePause, "idlepause", o.IdlePause, "pause a run after this long without input, 0 never pauses it")
+	flag.BoolVar(&o.RestartTips, "restarttips", o.RestartTips, "show a tip after restarting a few times in quick succession")
+	flag.DurationVar(&o.AutoRestart, "autorestart", o.AutoRestart, "start a new game this long after game over, like 5s, for kiosks and demos")
+	flag.StringVar(&o.SaveFile, "savefile", o.SaveFile, "quicksave file, saved with F5 and loaded with F9, empty keeps the save in memory")
+	recordDir := flag.String("record", "", "write a replay of every run to this directory")
+	replay := flag.String("replay", "", "play back the replay `file` written with -record")
+	statsDir := flag.String("stats-dir", "", "write a JSON summary of every run to this directory")
+	hud := flag.String("hudcolor", "", "HUD text color as hex RRGGBB")
+	theme := flag.String("theme", "", "colors, one of "+strings.Join(snake.ThemeNames(), ", ")+" or the path of a JSON theme file")
+	flag.Int64Var(&o.Seed, "seed", o.Seed, "seed for the food placement of every run, 0 picks a random seed")
+	flag.BoolVar(&o.Daily, "daily", o.Daily, "play the daily challenge, the same food for everyone on the same day")
+	headless := flag.Bool("headless", false, "play -games games without a window as fast as possible with the -autopilot bot, default greedy, and print their stats as JSON lines")
+	games := flag.Int("games", 1, "number of games played with -headless")
+	maxTicks := flag.Int64("maxticks", 60*60*60, "ticks after which a -headless game is stopped, 0 does not stop it")
+	terminal := flag.Bool("tui", false, "play in the terminal instead of a window, try a smaller board like -cells 30x20")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *cells != "" {
+		if _, err := fmt.Sscanf(*cells, "%dx%d", &o.CellsX, &o.CellsY); err != nil {
+			log.Fatalf("invalid -cells %q, expected COLSxROWS", *cells)
+		}
+	}
+	if *level != "" {
+		l, err := game.LoadLevel(*level)
+		if err != nil {
+			log.Fatalf("could not load level: %v", err)
+		}
+		o.Level = l
+	}
+	b, ok := bot.Lookup(*botName)
+	if !ok {
+		log.Fatalf("unknown bot %q", *botName)
+	}
+	o.Bot = b
+	if *autopilot != "" {
+		b, ok := bot.Lookup(*autopilot)
+		if !ok {
+			log.Fatalf("unknown bot %q", *autopilot)
+		}
+		o.Autopilot = b
+	}
+	if o.Speed < snake.MinSpeed {
+		log.Printf("invalid speed %v, using %v", o.Speed, snake.MinSpeed)
+		o.Speed = snake.MinSpeed
+	}
+	if err := o.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	switch *filter {
+	case "nearest":
+		o.Filter = ebiten.FilterNearest
+	case "linear":
+		o.Filter = ebiten.FilterLinear
+	default:
+		log.Printf("unknown filter %q, using nearest", *filter)
+	}
+
+	if *theme != "" {
+		if t, ok := snake.LookupTheme(*theme); ok {
+			o.Theme = t
+		} else if t, err := config.LoadTheme(*theme); err != nil {
+			log.Printf("could not load the theme: %v", err)
+		} else {
+			o.Theme = t
+		}
+	}
+	if *hud != "" {
+		clr, err := config.ParseColor(*hud)
+		if err != nil {
+			log.Printf("invalid HUD color: %v", err)
+		} else {
+			o.Theme.HUD = clr
+		}
+	}
+
+	if *statsDir != "" {
+		o.OnGameOver = func(stats snake.Stats) {
+			if err := writeStats(*statsDir, stats); err != nil {
+				log.Printf("could not write stats: %v", err)
+			}
+		}
+	}
+
+	if *recordDir != "" {
+		o.OnReplay = func(r *snake.Replay) {
+			if err := writeReplay(*recordDir, r); err != nil {
+				log.Printf("could not write replay: %v", err)
+			}
+		}
+	}
+	if *replay != "" {
+		r, err := snake.LoadReplay(*replay)
+		if err != nil {
+			log.Fatalf("could not load replay: %v", err)
+		}
+		o.Replay = r
+	}
+
+	if *headless {
+		if o.Autopilot == nil {
+			o.Autopilot = bot.Greedy{}
+		}
+		rules, err := o.Rules()
+		if err != nil {
+			log.Fatalf("could not load the campaign: %v", err)
+		}
+		if err := runHeadless(os.Stdout, rules, o.Seed, *games, *maxTicks); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *terminal {
+		rules, err := o.Rules()
+		if err != nil {
+			log.Fatalf("could not load the campaign: %v", err)
+		}
+		if err := tui.Run(tui.Options{Rules: rules, Seed: o.Seed}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	o.Scale = screenScale
+	g, err := snake.NewGame(o)
+	if err != nil {
+		log.Fatal(err)
+	}
+	update := g.Update
+	if runtime.GOOS == "js" {
+		// the board is resized with the browser window
+		update = fitWindow(update)
+	}
+	if err := ebiten.Run(update, o.Width, o.Height, o.Scale, title); err != nil {
+		if err == snake.ErrEnd {
+			return
+		}
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: %s [flags]
+
+Plays snake in a window. Settings are read from %s
+first and can be overridden with these flags:
+
+`, os.Args[0], config.DefaultPath())
+	flag.PrintDefaults()
+}
+
+// writeStats writes the stats of a run as JSON to a new file in dir.
+func writeStats(dir string, stats snake.Stats) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, "snake-"+stats.Time.Format("20060102-150405")+".json")
+	return ioutil.WriteFile(name, b, 0644)
+}
+
+// writeReplay writes r into dir, named after the current time.
+func writeReplay(dir string, r *snake.Replay) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := filepath.Join(dir, "snake-"+time.Now().Format("20060102-150405")+".replay")
+	return r.Save(name)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wongak/snake"
+)
+
+func TestWriteStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snake-stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stats := snake.Stats{Score: 1230, Seed: 1539600000123456789, Time: time.Date(2018, 10, 15, 12, 0, 0, 0, time.UTC)}
+	if err := writeStats(dir, stats); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "snake-20181015-120000.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Score int64 `json:"score"`
+		Seed  int64 `json:"seed"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Seed != stats.Seed || got.Score != stats.Score {
+		t.Errorf("stats written with seed %d and score %d, want %d and %d", got.Seed, got.Score, stats.Seed, stats.Score)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten"
+)
+
+// screenScale is the number of window pixels per screen pixel.
+const screenScale = 2
+
+// fitWindow wraps update to size the screen to the browser window,
+// which browsers report as the monitor size. The game adapts to the new
+// size on its next update.
+func fitWindow(update func(*ebiten.Image) error) func(*ebiten.Image) error {
+	var w, h int
+	return func(screen *ebiten.Image) error {
+		if mw, mh := ebiten.MonitorSize(); mw != w || mh != h {
+			w, h = mw, mh
+			if sw, sh := w/screenScale, h/screenScale; sw > 0 && sh > 0 {
+				ebiten.SetScreenSize(sw, sh)
+			}
+		}
+		return update(screen)
+	}
+}
@@ -0,0 +1,374 @@
+// Package config loads the game settings from a TOML file.
+package config
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake"
+)
+
+// Config are the settings read from the config file.
+type Config struct {
+	Width         int     `toml:"width"`
+	Height        int     `toml:"height"`
+	Fullscreen    bool    `toml:"fullscreen"`
+	IntegerScale  bool    `toml:"integer_scale"`
+	CellsX        int     `toml:"cells_x"`
+	CellsY        int     `toml:"cells_y"`
+	CellSize      int     `toml:"cell_size"`
+	Filter        string  `toml:"filter"`
+	InitialLength int     `toml:"initial_length"`
+	Speed         float64 `toml:"speed"`
+	SpeedUp       float64 `toml:"speed_up"`
+	GrowPerFood   int     `toml:"grow_per_food"`
+	HighScoreFile string  `toml:"high_score_file"`
+	Leaderboard   string  `toml:"leaderboard"`
+	Walls         bool    `toml:"walls"`
+	Sound         bool    `toml:"sound"`
+	Smooth        bool    `toml:"smooth"`
+	Theme         string  `toml:"theme"`
+	Colors        Colors  `toml:"colors"`
+	// Keys maps action names to key names.
+	Keys map[string][]string `toml:"keys"`
+}
+
+// Colors are the theme colors in hex notation RRGGBB.
+type Colors struct {
+	Background string `toml:"background" json:"background"`
+	Border     string `toml:"border" json:"border"`
+	Snake      string `toml:"snake" json:"snake"`
+	Head       string `toml:"head" json:"head"`
+	Rival      string `toml:"rival" json:"rival"`
+	RivalHead  string `toml:"rival_head" json:"rival_head"`
+	Food       string `toml:"food" json:"food"`
+	Forbidden  string `toml:"forbidden" json:"forbidden"`
+	Obstacle   string `toml:"obstacle" json:"obstacle"`
+	Warning    string `toml:"warning" json:"warning"`
+	HUD        string `toml:"hud" json:"hud"`
+}
+
+// colorsOf returns the colors of theme t.
+func colorsOf(t snake.Theme) Colors {
+	return Colors{
+		Background: FormatColor(t.Background),
+		Border:     FormatColor(t.Border),
+		Snake:      FormatColor(t.Snake),
+		Head:       FormatColor(t.Head),
+		Rival:      FormatColor(t.Rival),
+		RivalHead:  FormatColor(t.RivalHead),
+		Food:       FormatColor(t.Food),
+		Forbidden:  FormatColor(t.Forbidden),
+		Obstacle:   FormatColor(t.Obstacle),
+		Warning:    FormatColor(t.Warning),
+		HUD:        FormatColor(t.HUD),
+	}
+}
+
+// apply sets the colors of t. If they differ from its colors, t is no
+// longer the built-in theme it was and loses its name.
+func (c Colors) apply(t *snake.Theme) error {
+	before := *t
+	colors := []struct {
+		hex string
+		dst *color.RGBA
+	}{
+		{c.Background, &t.Background},
+		{c.Border, &t.Border},
+		{c.Snake, &t.Snake},
+		{c.Head, &t.Head},
+		{c.Rival, &t.Rival},
+		{c.RivalHead, &t.RivalHead},
+		{c.Food, &t.Food},
+		{c.Forbidden, &t.Forbidden},
+		{c.Obstacle, &t.Obstacle},
+		{c.Warning, &t.Warning},
+		{c.HUD, &t.HUD},
+	}
+	for _, clr := range colors {
+		rgba, err := ParseColor(clr.hex)
+		if err != nil {
+			return err
+		}
+		*clr.dst = rgba
+	}
+	if *t != before {
+		t.Name = ""
+	}
+	return nil
+}
+
+// DefaultPath returns the path of the config file in the user's config
+// directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "config.toml")
+}
+
+// Default returns the config matching snake.DefaultOptions.
+func Default() *Config {
+	o := snake.DefaultOptions()
+	return &Config{
+		Width:         o.Width,
+		Height:        o.Height,
+		Fullscreen:    o.Fullscreen,
+		IntegerScale:  o.IntegerScale,
+		CellsX:        o.CellsX,
+		CellsY:        o.CellsY,
+		CellSize:      o.CellSize,
+		Filter:        "nearest",
+		InitialLength: o.InitialLength,
+		Speed:         o.Speed,
+		SpeedUp:       o.SpeedUp,
+		GrowPerFood:   o.GrowPerFood,
+		HighScoreFile: o.HighScoreFile,
+		Leaderboard:   o.Leaderboard,
+		Walls:         o.Walls,
+		Sound:         o.Sound,
+		Smooth:        o.Smooth,
+		Theme:         o.Theme.Name,
+		Colors:        colorsOf(o.Theme),
+		Keys:          keyNames(o.Keys),
+	}
+}
+
+// keyNames returns the names of the keys of every action.
+func keyNames(m snake.Keymap) map[string][]string {
+	names := make(map[string][]string, len(m))
+	for a, keys := range m {
+		for _, k := range keys {
+			names[a.String()] = append(names[a.String()], k.String())
+		}
+	}
+	return names
+}
+
+// SetKeymap replaces the keys with m.
+func (c *Config) SetKeymap(m snake.Keymap) {
+	c.Keys = keyNames(m)
+}
+
+// SetOptions takes over the settings the player can change in game:
+// the board size, the speed, the walls, the sound, the smooth movement,
+// the colors and the keys.
+func (c *Config) SetOptions(o snake.Options) {
+	c.CellsX, c.CellsY = o.CellsX, o.CellsY
+	c.Speed = o.Speed
+	c.Walls = o.Walls
+	c.Sound = o.Sound
+	c.Smooth = o.Smooth
+	c.Theme = o.Theme.Name
+	c.Colors = colorsOf(o.Theme)
+	c.SetKeymap(o.Keys)
+}
+
+// Load reads the config file at path. Settings missing from the file
+// keep their defaults. If there is no file yet, a commented default
+// config is written to path.
+func Load(path string) (*Config, error) {
+	c := Default()
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, c.Save(path)
+	}
+	if err != nil {
+		return c, err
+	}
+	if _, err := toml.Decode(string(b), c); err != nil {
+		return Default(), fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+const template = `# snake configuration
+
+# window size in pixels
+width = %d
+height = %d
+# start in fullscreen, F11 toggles it in game
+fullscreen = %t
+# keep the screen at width by height pixels and scale it by whole
+# factors only, instead of laying the board out for larger screens
+integer_scale = %t
+
+# board size in cells
+cells_x = %d
+cells_y = %d
+# cell size in pixels, 0 fits the board into the window
+cell_size = %d
+# tile filter, "nearest" or "linear"
+filter = %q
+
+initial_length = %d
+# frames between two steps at the start, lower is faster
+speed = %.1f
+# points it takes to shorten the step interval by one frame, 0 keeps
+# the speed constant
+speed_up = %.1f
+# segments grown per food, 0 grows faster with higher points
+grow_per_food = %d
+
+# high score table, empty keeps the scores in memory
+high_score_file = %q
+# URL of an online leaderboard the scores are posted to, empty keeps
+# them offline
+leaderboard = %q
+
+# solid board edges instead of wrapping around
+walls = %t
+
+# music and sound effects
+sound = %t
+# draw the snake gliding between cells instead of jumping
+smooth = %t
+
+# built-in color theme: %s
+# the colors below are applied on top of it
+theme = %q
+
+# colors in hex notation RRGGBB
+[colors]
+background = %q
+border = %q
+snake = %q
+head = %q
+# snake of player two
+rival = %q
+rival_head = %q
+food = %q
+forbidden = %q
+obstacle = %q
+warning = %q
+hud = %q
+
+# keys of every action, named like "Up", "W", "Space" or "Enter"
+[keys]
+%s`
+
+// Save writes the config to path, with comments explaining the
+// settings.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	s := fmt.Sprintf(template,
+		c.Width, c.Height, c.Fullscreen, c.IntegerScale,
+		c.CellsX, c.CellsY, c.CellSize, c.Filter,
+		c.InitialLength, c.Speed, c.SpeedUp, c.GrowPerFood,
+		c.HighScoreFile, c.Leaderboard,
+		c.Walls,
+		c.Sound, c.Smooth,
+		strings.Join(snake.ThemeNames(), ", "), c.Theme,
+		c.Colors.Background, c.Colors.Border, c.Colors.Snake, c.Colors.Head,
+		c.Colors.Rival, c.Colors.RivalHead,
+		c.Colors.Food, c.Colors.Forbidden, c.Colors.Obstacle, c.Colors.Warning, c.Colors.HUD,
+		c.keys(),
+	)
+	return ioutil.WriteFile(path, []byte(s), 0644)
+}
+
+// keys formats the keys table in the order of snake.Actions.
+func (c *Config) keys() string {
+	var b strings.Builder
+	for _, a := range snake.Actions() {
+		names, ok := c.Keys[a.String()]
+		if !ok {
+			continue
+		}
+		quoted := make([]string, len(names))
+		for i, n := range names {
+			quoted[i] = strconv.Quote(n)
+		}
+		fmt.Fprintf(&b, "%s = [%s]\n", a, strings.Join(quoted, ", "))
+	}
+	return b.String()
+}
+
+// Apply sets the options from the config.
+func (c *Config) Apply(o *snake.Options) error {
+	o.Width, o.Height = c.Width, c.Height
+	o.Fullscreen = c.Fullscreen
+	o.IntegerScale = c.IntegerScale
+	o.CellsX, o.CellsY = c.CellsX, c.CellsY
+	o.CellSize = c.CellSize
+	switch c.Filter {
+	case "nearest":
+		o.Filter = ebiten.FilterNearest
+	case "linear":
+		o.Filter = ebiten.FilterLinear
+	default:
+		return fmt.Errorf("unknown filter %q", c.Filter)
+	}
+	o.InitialLength = c.InitialLength
+	o.Speed = c.Speed
+	o.SpeedUp = c.SpeedUp
+	o.GrowPerFood = c.GrowPerFood
+	o.HighScoreFile = c.HighScoreFile
+	o.Leaderboard = c.Leaderboard
+	o.Walls = c.Walls
+	o.Sound = c.Sound
+	o.Smooth = c.Smooth
+
+	if c.Theme != "" {
+		t, ok := snake.LookupTheme(c.Theme)
+		if !ok {
+			return fmt.Errorf("unknown theme %q", c.Theme)
+		}
+		o.Theme = t
+	}
+	if err := c.Colors.apply(&o.Theme); err != nil {
+		return err
+	}
+
+	keys := snake.DefaultKeymap()
+	for name, names := range c.Keys {
+		a, ok := parseAction(name)
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		keys[a] = nil
+		for _, n := range names {
+			k, ok := snake.ParseKey(n)
+			if !ok {
+				return fmt.Errorf("unknown key %q for %s", n, name)
+			}
+			keys[a] = append(keys[a], k)
+		}
+	}
+	o.Keys = keys
+	return nil
+}
+
+func parseAction(name string) (snake.Action, bool) {
+	for _, a := range snake.Actions() {
+		if a.String() == name {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+// ParseColor parses a color in hex notation RRGGBB with an optional
+// leading #.
+func ParseColor(s string) (color.RGBA, error) {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(strings.TrimPrefix(s, "#"), "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{}, fmt.Errorf("%q is not a RRGGBB color", s)
+	}
+	return color.RGBA{r, g, b, 0xff}, nil
+}
+
+// FormatColor formats a color in hex notation #RRGGBB.
+func FormatColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/wongak/snake"
+)
+
+// LoadTheme reads a custom theme from the JSON file at path, an object
+// with the colors keyed like in the colors table of the config file:
+//
+//	{"background": "#000000", "snake": "#ffcc00", "hud": "#ffffff"}
+//
+// Colors missing from the file are the ones of the classic theme.
+func LoadTheme(path string) (snake.Theme, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snake.Theme{}, err
+	}
+	t := snake.DefaultTheme
+	c := colorsOf(t)
+	if err := json.Unmarshal(b, &c); err != nil {
+		return snake.Theme{}, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := c.apply(&t); err != nil {
+		return snake.Theme{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+package snake
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// updateControls handles the controls screen. The screen uses the arrow
+// keys, Enter and Escape regardless of the keymap, so a bad mapping
+// cannot lock the player out.
+func (g *Game) updateControls() {
+	if g.rebinding {
+		k, ok := justPressedKey()
+		if !ok {
+			return
+		}
+		g.rebinding = false
+		if k == ebiten.KeyEscape {
+			return
+		}
+		g.bind(g.controls, k)
+		return
+	}
+	actions := Actions()
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.state = g.controlsFrom
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		g.rebinding = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		g.controls = (g.controls + Action(len(actions)) - 1) % Action(len(actions))
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		g.controls = (g.controls + 1) % Action(len(actions))
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		g.setKeymap(DefaultKeymap())
+	}
+}
+
+// bind makes k the only key of a.
+func (g *Game) bind(a Action, k ebiten.Key) {
+	keys := make(Keymap, len(g.options.Keys))
+	for action, k := range g.options.Keys {
+		keys[action] = k
+	}
+	keys[a] = []ebiten.Key{k}
+	g.setKeymap(keys)
+}
+
+func (g *Game) setKeymap(keys Keymap) {
+	g.options.Keys = keys
+	if g.options.OnKeymapChange != nil {
+		g.options.OnKeymapChange(keys)
+	}
+}
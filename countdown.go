@@ -0,0 +1,41 @@
+package snake
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten"
+	"golang.org/x/image/font"
+)
+
+// countdownFrames is the number of frames counted down before the snake
+// starts moving, one second per number.
+const countdownFrames = 3 * 60
+
+// countdownScale is how much larger than the HUD the numbers are drawn.
+const countdownScale = 4
+
+// startCountdown holds the snake for a countdown, so the player is not
+// caught off guard when a run starts or continues.
+func (g *Game) startCountdown() {
+	g.countdown = countdownFrames
+}
+
+// resume continues a paused run after a countdown.
+func (g *Game) resume() {
+	g.state = StatePlaying
+	g.startCountdown()
+}
+
+// drawCountdown draws the number of seconds left in the middle of the
+// screen, every number fading out within its second.
+func (g *Game) drawCountdown(canvas *ebiten.Image) {
+	if g.countdown == 0 {
+		return
+	}
+	w := g.w
+	str := strconv.Itoa((g.countdown + 59) / 60)
+	alpha := float64((g.countdown-1)%60+1) / 60
+	tw := font.MeasureString(g.face, str).Ceil() * countdownScale
+	ascent := g.face.Metrics().Ascent.Ceil() * countdownScale
+	g.drawTextScaled(canvas, str, (w.screenW-tw)/2, (w.screenH+ascent)/2, w.theme.Warning, alpha, countdownScale)
+}
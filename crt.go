@@ -0,0 +1,44 @@
+package snake
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// crt renders the game offscreen and draws it to the screen through a
+// retro CRT look with scanlines and a vignette.
+type crt struct {
+	canvas  *ebiten.Image
+	overlay *ebiten.Image
+}
+
+func newCRT(w, h int) *crt {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	cx, cy := float64(w)/2, float64(h)/2
+	maxDist := math.Hypot(cx, cy)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := 0.0
+			if y%2 == 1 {
+				// scanline
+				a = 0.25
+			}
+			d := math.Hypot(float64(x)-cx, float64(y)-cy) / maxDist
+			a = 1 - (1-a)*(1-0.6*d*d)
+			img.Set(x, y, color.RGBA{0, 0, 0, uint8(a * 0xff)})
+		}
+	}
+	c := &crt{}
+	c.canvas, _ = ebiten.NewImage(w, h, ebiten.FilterNearest)
+	c.overlay, _ = ebiten.NewImageFromImage(img, ebiten.FilterNearest)
+	return c
+}
+
+// draw draws the offscreen canvas with the effect applied to screen.
+func (c *crt) draw(screen *ebiten.Image) {
+	screen.DrawImage(c.canvas, &ebiten.DrawImageOptions{})
+	screen.DrawImage(c.overlay, &ebiten.DrawImageOptions{})
+}
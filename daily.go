@@ -0,0 +1,11 @@
+package snake
+
+import (
+	"time"
+)
+
+// dailyDate returns the date of today's challenge. The date is taken in
+// UTC so players in all time zones share the challenge.
+func dailyDate() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,130 @@
+package snake
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// gridColor is the color of the grid lines of the debug overlay.
+var gridColor = color.RGBA{0xff, 0xff, 0xff, 0x30}
+
+// debug is the state of the debug overlay.
+type debug struct {
+	// shown and grid are toggled with F3 and F4
+	shown, grid bool
+	// tps is the number of ticks run per second, measured from the
+	// frames of the run since since
+	tps   float64
+	frame int64
+	since time.Time
+	// steps and draws count the steps of the snake and the frames drawn,
+	// stepRate and drawRate are their number per second measured like
+	// tps from steps and draws at since
+	steps, draws       int64
+	stepRate, drawRate float64
+	stepsAt, drawsAt   int64
+	// caughtUp is the number of ticks the last update ran, more than
+	// one while the clock catches up after a slow frame
+	caughtUp int
+	// line is a pixel stretched into the grid lines
+	line *ebiten.Image
+}
+
+// updateDebug toggles the debug overlay and measures the tick rate. It
+// does nothing without Options.Debug.
+func (g *Game) updateDebug() {
+	if !g.options.Debug {
+		return
+	}
+	d := &g.debug
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		d.shown = !d.shown
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		d.grid = !d.grid
+	}
+	now := time.Now()
+	frame := g.sim.Frame()
+	if frame < d.frame || d.since.IsZero() {
+		// a new run started
+		d.frame, d.since = frame, now
+		d.stepsAt, d.drawsAt = d.steps, d.draws
+	}
+	if dt := now.Sub(d.since); dt >= time.Second {
+		d.tps = float64(frame-d.frame) / dt.Seconds()
+		d.stepRate = float64(d.steps-d.stepsAt) / dt.Seconds()
+		d.drawRate = float64(d.draws-d.drawsAt) / dt.Seconds()
+		d.frame, d.since = frame, now
+		d.stepsAt, d.drawsAt = d.steps, d.draws
+	}
+}
+
+// countTicks counts the ticks run by an update and the steps of the
+// snake among them.
+func (d *debug) countTicks(ticks, steps int) {
+	d.caughtUp = ticks
+	d.steps += int64(steps)
+}
+
+// drawDebug draws the grid lines and the debug overlay if shown.
+func (g *Game) drawDebug(canvas *ebiten.Image) {
+	d := &g.debug
+	if d.grid {
+		g.drawGrid(canvas)
+	}
+	if !d.shown {
+		return
+	}
+	s := g.sim.Snake()
+	h := s.Head()
+	lines := []string{
+		fmt.Sprintf("fps %.1f  tps %.1f/%.0f", ebiten.CurrentFPS(), d.tps, g.clock.rate()),
+		fmt.Sprintf("frame %d  step every %d ticks", g.sim.Frame(), g.sim.StepInterval()),
+		fmt.Sprintf("steps %d (%.1f/s)  draws %d (%.1f/s)", d.steps, d.stepRate, d.draws, d.drawRate),
+		fmt.Sprintf("ticks last update %d", d.caughtUp),
+		fmt.Sprintf("head %d,%d  direction %d", h.X, h.Y, s.Direction()),
+		fmt.Sprintf("length %d  growing %d", s.Len(), s.Growing()),
+	}
+	for _, f := range g.sim.Foods() {
+		lines = append(lines, fmt.Sprintf("%s food %d,%d", f.Kind.Name, f.X, f.Y))
+	}
+	for _, p := range g.sim.Poisons() {
+		lines = append(lines, fmt.Sprintf("poison %d,%d", p.X, p.Y))
+	}
+	w := g.w
+	lh := g.face.Metrics().Height.Ceil()
+	for i, line := range lines {
+		g.drawText(canvas, line, w.cellW*2, w.cellH*2+(i+1)*lh, w.theme.Warning, 1)
+	}
+}
+
+// drawGrid draws a line between every two cells of the board.
+func (g *Game) drawGrid(canvas *ebiten.Image) {
+	d := &g.debug
+	if d.line == nil {
+		d.line, _ = ebiten.NewImage(1, 1, ebiten.FilterNearest)
+		d.line.Fill(gridColor)
+	}
+	w := g.w
+	x0, y0 := w.cellToPixel(0, 0)
+	x1, y1 := w.cellToPixel(w.cellsX+1, w.cellsY+1)
+	op := &ebiten.DrawImageOptions{}
+	for x := 0; x <= w.cellsX+1; x++ {
+		px, _ := w.cellToPixel(x, 0)
+		op.GeoM.Reset()
+		op.GeoM.Scale(1, y1-y0)
+		op.GeoM.Translate(px-w.camX, y0-w.camY)
+		canvas.DrawImage(d.line, op)
+	}
+	for y := 0; y <= w.cellsY+1; y++ {
+		_, py := w.cellToPixel(0, y)
+		op.GeoM.Reset()
+		op.GeoM.Scale(x1-x0, 1)
+		op.GeoM.Translate(x0-w.camX, py-w.camY)
+		canvas.DrawImage(d.line, op)
+	}
+}
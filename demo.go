@@ -0,0 +1,85 @@
+package snake
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/bot"
+)
+
+// demoDelay is the number of frames idling on the menu before the demo
+// starts.
+const demoDelay = 10 * 60
+
+// updateIdle counts the frames without input on the menu and starts the
+// demo once the player was idle long enough.
+func (g *Game) updateIdle(input bool) {
+	if input {
+		g.idle = 0
+		return
+	}
+	g.idle++
+	if g.idle >= demoDelay {
+		g.startDemo()
+	}
+}
+
+// startDemo plays a game on autopilot against the computer snake. The
+// options of the player are kept aside until the demo stops.
+func (g *Game) startDemo() {
+	if !g.demo {
+		g.menuOptions = g.options
+		g.demo = true
+	}
+	g.options.TwoPlayer, g.options.Coop = false, false
+	g.options.AI = true
+	g.options.Autopilot = bot.Greedy{}
+	g.Reset()
+	g.countdown = 0
+	g.state = StateDemo
+}
+
+// updateDemo advances the demo, restarting it when it ends, and stops
+// it on any input.
+func (g *Game) updateDemo(input bool) {
+	if input {
+		g.stopDemo()
+		return
+	}
+	g.advance()
+	if g.state != StateDemo {
+		g.startDemo()
+	}
+}
+
+// stopDemo restores the options of the player and returns to the menu.
+func (g *Game) stopDemo() {
+	g.options = g.menuOptions
+	g.demo = false
+	g.Reset()
+	g.state = StateMenu
+	g.idle = 0
+}
+
+// anyInput reports whether a key, a gamepad button or the touch screen
+// was just pressed.
+func anyInput() bool {
+	if _, ok := justPressedKey(); ok {
+		return true
+	}
+	if len(inpututil.JustPressedTouchIDs()) > 0 {
+		return true
+	}
+	for _, id := range ebiten.GamepadIDs() {
+		for b := ebiten.GamepadButton(0); b <= ebiten.GamepadButtonMax; b++ {
+			if inpututil.IsGamepadButtonJustPressed(id, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *Game) drawDemo(canvas *ebiten.Image) {
+	w := g.w
+	g.drawText(canvas, "DEMO - press any key", w.cellW*2, g.hudRow(3), w.theme.Warning, 1)
+}
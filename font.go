@@ -0,0 +1,217 @@
+package snake
+
+import (
+	"image"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/inconsolata"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// minFontSize is the smallest font size in device pixels, keeping text
+// readable in small windows.
+const minFontSize = 13
+
+// hudFont is the bundled TrueType font of the HUD and the overlays.
+var hudFont *sfnt.Font
+
+func init() {
+	f, err := sfnt.Parse(gomono.TTF)
+	if err != nil {
+		log.Printf("could not parse the font, falling back to the bitmap font: %v", err)
+		return
+	}
+	hudFont = f
+}
+
+// newHUDFace returns the font of the HUD for a screen of the given
+// height, sized to take up hudFraction of it. The screen is shown with
+// scale device pixels per screen pixel, see hudScale.
+func newHUDFace(screenH int, scale float64) font.Face {
+	if hudFont == nil {
+		return inconsolata.Regular8x16
+	}
+	size := float64(screenH) * hudFraction
+	if size*scale < minFontSize {
+		size = minFontSize / scale
+	}
+	return newTTFFace(hudFont, size)
+}
+
+// hudScale returns the number of device pixels per screen pixel for a
+// window scaled by scale, taking the scale of high-DPI displays into
+// account.
+func hudScale(scale float64) float64 {
+	if scale <= 0 {
+		scale = 1
+	}
+	if d := ebiten.DeviceScaleFactor(); d > 0 {
+		scale *= d
+	}
+	return scale
+}
+
+// ttfFace renders the glyphs of a TrueType font at one size. The
+// outlines are filled with a vector rasterizer and the masks kept for
+// the next time a glyph is drawn.
+type ttfFace struct {
+	f      *sfnt.Font
+	buf    sfnt.Buffer
+	ppem   fixed.Int26_6
+	glyphs map[rune]*ttfGlyph
+}
+
+// ttfGlyph is a rasterized glyph. bounds are relative to the dot.
+type ttfGlyph struct {
+	bounds  image.Rectangle
+	mask    *image.Alpha
+	advance fixed.Int26_6
+	ok      bool
+}
+
+// newTTFFace returns a face drawing f with size pixels per em.
+func newTTFFace(f *sfnt.Font, size float64) *ttfFace {
+	return &ttfFace{
+		f:      f,
+		ppem:   fixed.Int26_6(size * 64),
+		glyphs: make(map[rune]*ttfGlyph),
+	}
+}
+
+// Close implements font.Face.
+func (t *ttfFace) Close() error {
+	return nil
+}
+
+// Metrics implements font.Face.
+func (t *ttfFace) Metrics() font.Metrics {
+	m, err := t.f.Metrics(&t.buf, t.ppem, font.HintingNone)
+	if err != nil {
+		return font.Metrics{}
+	}
+	return m
+}
+
+// Kern implements font.Face.
+func (t *ttfFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	x0, _ := t.f.GlyphIndex(&t.buf, r0)
+	x1, _ := t.f.GlyphIndex(&t.buf, r1)
+	k, err := t.f.Kern(&t.buf, x0, x1, t.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return k
+}
+
+// GlyphAdvance implements font.Face.
+func (t *ttfFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	x, err := t.f.GlyphIndex(&t.buf, r)
+	if err != nil {
+		return 0, false
+	}
+	advance, err := t.f.GlyphAdvance(&t.buf, x, t.ppem, font.HintingNone)
+	return advance, err == nil
+}
+
+// GlyphBounds implements font.Face.
+func (t *ttfFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	g := t.glyph(r)
+	b := fixed.Rectangle26_6{
+		Min: fixed.P(g.bounds.Min.X, g.bounds.Min.Y),
+		Max: fixed.P(g.bounds.Max.X, g.bounds.Max.Y),
+	}
+	return b, g.advance, g.ok
+}
+
+// Glyph implements font.Face. The glyph is placed on whole pixels.
+func (t *ttfFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	g := t.glyph(r)
+	if !g.ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	dr := g.bounds.Add(image.Point{dot.X.Round(), dot.Y.Round()})
+	return dr, g.mask, image.Point{}, g.advance, true
+}
+
+// glyph returns the rasterized glyph of r, rasterizing it on first use.
+func (t *ttfFace) glyph(r rune) *ttfGlyph {
+	if g, ok := t.glyphs[r]; ok {
+		return g
+	}
+	g := t.rasterize(r)
+	t.glyphs[r] = g
+	return g
+}
+
+func (t *ttfFace) rasterize(r rune) *ttfGlyph {
+	g := &ttfGlyph{mask: image.NewAlpha(image.Rectangle{})}
+	x, err := t.f.GlyphIndex(&t.buf, r)
+	if err != nil || x == 0 && r != 0 {
+		return g
+	}
+	if g.advance, err = t.f.GlyphAdvance(&t.buf, x, t.ppem, font.HintingNone); err != nil {
+		return g
+	}
+	g.ok = true
+	segs, err := t.f.LoadGlyph(&t.buf, x, t.ppem, nil)
+	if err != nil || len(segs) == 0 {
+		// blank glyphs like the space only advance
+		return g
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range segs {
+		for _, p := range s.Args[:segmentArgs(s.Op)] {
+			px, py := float64(p.X)/64, float64(p.Y)/64
+			minX, minY = math.Min(minX, px), math.Min(minY, py)
+			maxX, maxY = math.Max(maxX, px), math.Max(maxY, py)
+		}
+	}
+	g.bounds = image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	w, h := g.bounds.Dx(), g.bounds.Dy()
+	if w == 0 || h == 0 {
+		return g
+	}
+	ox, oy := float32(g.bounds.Min.X), float32(g.bounds.Min.Y)
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X)/64 - ox, float32(p.Y)/64 - oy
+	}
+	z := vector.NewRasterizer(w, h)
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			z.MoveTo(pt(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			z.LineTo(pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			bx, by := pt(s.Args[0])
+			cx, cy := pt(s.Args[1])
+			z.QuadTo(bx, by, cx, cy)
+		case sfnt.SegmentOpCubeTo:
+			bx, by := pt(s.Args[0])
+			cx, cy := pt(s.Args[1])
+			dx, dy := pt(s.Args[2])
+			z.CubeTo(bx, by, cx, cy, dx, dy)
+		}
+	}
+	g.mask = image.NewAlpha(image.Rect(0, 0, w, h))
+	z.Draw(g.mask, g.mask.Bounds(), image.Opaque, image.Point{})
+	return g
+}
+
+// segmentArgs returns the number of points a segment of op uses.
+func segmentArgs(op sfnt.SegmentOp) int {
+	switch op {
+	case sfnt.SegmentOpQuadTo:
+		return 2
+	case sfnt.SegmentOpCubeTo:
+		return 3
+	}
+	return 1
+}
@@ -0,0 +1,33 @@
+package snake
+
+import "testing"
+
+func TestHUDFace(t *testing.T) {
+	tests := []struct {
+		screenH int
+		scale   float64
+	}{
+		{400, 1},
+		{400, 2},
+		{240, 1},
+		{240, 3},
+		{1080, 1},
+		{1080, 2},
+	}
+	for _, tt := range tests {
+		face := newHUDFace(tt.screenH, tt.scale)
+		m := face.Metrics()
+		if size := float64(m.Ascent.Ceil()+m.Descent.Ceil()) * tt.scale; size < minFontSize {
+			t.Errorf("%d pixels at scale %g: text %g device pixels high, want at least %d", tt.screenH, tt.scale, size, minFontSize)
+		}
+		g := &Game{face: face}
+		for n := 0; n < 8; n++ {
+			if gap := g.hudRow(n+1) - g.hudRow(n); gap < m.Ascent.Ceil()+m.Descent.Ceil() {
+				t.Errorf("%d pixels at scale %g: line %d is %d pixels below line %d, overlapping it", tt.screenH, tt.scale, n+1, gap, n)
+			}
+		}
+		if y := g.hudRow(8) + m.Descent.Ceil(); y > tt.screenH {
+			t.Errorf("%d pixels at scale %g: line 8 ends at %d, off the screen", tt.screenH, tt.scale, y)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package snake
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// updateFullscreen toggles fullscreen with F11 and switches to the mode
+// of Options.Fullscreen if it differs from the current one.
+func (g *Game) updateFullscreen() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		g.options.Fullscreen = !g.options.Fullscreen
+	}
+	if g.options.Fullscreen != g.fullscreen {
+		g.setFullscreen(g.options.Fullscreen)
+	}
+}
+
+// setFullscreen switches between the window and fullscreen. Instead of
+// scaling up the window, the screen takes the size of the monitor in
+// fullscreen, so the board and the HUD are laid out for its resolution
+// on the next update. The monitor size is in device independent pixels,
+// ebiten adds the device scale of high-DPI displays on top. Leaving
+// fullscreen restores the size of the window.
+func (g *Game) setFullscreen(on bool) {
+	g.fullscreen = on
+	if on {
+		g.windowW, g.windowH = g.options.Width, g.options.Height
+		mw, mh := ebiten.MonitorSize()
+		scale := ebiten.ScreenScale()
+		if w, h := int(float64(mw)/scale), int(float64(mh)/scale); scale > 0 && w > 0 && h > 0 {
+			ebiten.SetScreenSize(w, h)
+		}
+	} else if g.windowW > 0 && g.windowH > 0 {
+		ebiten.SetScreenSize(g.windowW, g.windowH)
+	}
+	ebiten.SetFullscreen(on)
+}
@@ -0,0 +1,1413 @@
+package snake
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/audio"
+	"github.com/wongak/snake/bot"
+	"github.com/wongak/snake/game"
+	"github.com/wongak/snake/leaderboard"
+	"github.com/wongak/snake/netplay"
+	"golang.org/x/image/font"
+)
+
+// MinSpeed is the smallest number of ticks between two steps.
+const MinSpeed = game.MinSpeed
+
+// Options configures a game.
+type Options struct {
+	// Width and Height are the screen size in pixels.
+	Width, Height int
+	// Scale is the number of window pixels per screen pixel, as passed
+	// to ebiten.Run. The HUD text is sized to stay readable in the
+	// window. 0 is taken as 1.
+	Scale float64 `json:"-"`
+	// Fullscreen shows the game in fullscreen, laid out for the size of
+	// the monitor. F11 toggles it.
+	Fullscreen bool
+	// CellsX and CellsY are the number of cells on the board.
+	CellsX, CellsY int
+	// CellSize fixes the cell size in pixels. With 0 the board is fit
+	// into the screen, or, if the cells would get smaller than 12
+	// pixels, keeps that size. A board larger than the screen scrolls
+	// with the snake's head.
+	CellSize int
+	// Filter is the filter used for the tiles.
+	Filter ebiten.Filter
+
+	InitialLength int
+	// Speed is the initial number of ticks between two steps. It
+	// decreases as the points rise.
+	Speed float64
+	// SpeedUp is the number of points it takes to shorten the step
+	// interval by one tick. With 0 the speed stays constant.
+	SpeedUp float64
+	// TickRate is the number of ticks per second the rules advance by,
+	// DefaultTickRate if 0. The durations of the rules count in ticks,
+	// so raising it speeds up the whole game. The rate does not depend
+	// on how often the screen is drawn.
+	TickRate int
+	// Seed seeds the random placement of food, so every run places the
+	// same food. With 0 the seed is taken from the clock.
+	Seed int64
+	// Daily plays the daily challenge: every run is seeded with the
+	// current date, so all players get the same food on the same day.
+	// It overrides Seed, and every day has its own high score table.
+	Daily bool
+
+	// Effects enables visual effects like score popups and particle
+	// bursts.
+	Effects bool
+	// Chaos periodically reverses the controls.
+	Chaos bool
+	// Harvest spawns food in waves of this many items. A new wave
+	// appears once all items are eaten. With 0 there is a single food
+	// item which respawns immediately.
+	Harvest int
+	// Walls makes the board edges solid. Running into them ends the
+	// game instead of wrapping around. Wall games have their own high
+	// score table.
+	Walls bool
+	// Level is the arena layout. Its size replaces CellsX and CellsY.
+	// With nil the board is empty.
+	Level *game.Level
+	// TwoPlayer adds a second snake for a second player on the same
+	// keyboard. Player one steers with the arrow keys, player two with
+	// WASD. Running into the other snake ends the game. Power-ups and
+	// poison only affect player one, and there is no campaign.
+	TwoPlayer bool
+	// LongerWins lets the longer snake survive if the heads of both
+	// snakes of a two-player game meet. Otherwise both crash and the
+	// higher score wins.
+	LongerWins bool
+	// Coop adds a second snake for a second player like TwoPlayer, but
+	// both play together for a shared score. Every crash costs one of
+	// the shared lives, the snake starts over until none are left.
+	// There is no tron or campaign in co-op, and TwoPlayer wins.
+	Coop bool
+	// AI adds a computer snake which competes for the food. Running
+	// into it ends the game, a crashed computer snake comes back after
+	// a few seconds. It is ignored in two-player games.
+	AI bool
+	// Bot steers the computer snake, bot.Greedy if nil.
+	Bot bot.Bot `json:"-"`
+	// Autopilot steers the player's snake if set. Runs on autopilot do
+	// not enter the high score table.
+	Autopilot bot.Bot `json:"-"`
+	// Tron turns the game into a light-cycle race: the snake never
+	// shrinks, its whole trail stays on the board as a wall and there
+	// is no food. The score is the time survived. Tron games have their
+	// own high score table, and there is no campaign.
+	Tron bool
+	// Shrink closes the walls in on the arena by one ring of cells
+	// every 30 seconds, marked by a blinking band before. Snakes caught
+	// by the walls crash, surviving a shrink scores a bonus. Shrinking
+	// arenas have their own high score table, and there is no campaign.
+	Shrink bool
+	// Gauntlet spawns three obstacles every time the score rose by this
+	// many points, never on the snakes or the food, so the board gets
+	// more crowded the longer the run. 0 spawns none. Gauntlet runs
+	// have their own high score table.
+	Gauntlet int
+	// Campaign plays the built-in levels in order. Eating enough food
+	// completes a level and the next one is faster. It replaces Level.
+	Campaign bool
+	// Powerups spawns timed pickups which speed up or slow down the
+	// snake, shrink it or let it pass through its body for a while.
+	Powerups bool
+	// Poison occasionally places poison on the board. Eating it costs
+	// points and tail segments, and kills a short snake.
+	Poison bool
+	// Obstacles is the number of impassable blocks placed on the board
+	// at the start of every game.
+	Obstacles int
+	// WrapGrace ignores the head landing on the body on the step it
+	// wraps around the board edge. This gives the player the next step
+	// to steer off the body on small, fast boards, at the cost of
+	// letting the head overlap the body for that one step. Walls,
+	// obstacles and the other snake still count.
+	WrapGrace bool
+	// GrowPerFood is the number of segments the snake grows per food.
+	// With 0 the growth accelerates with the points instead.
+	GrowPerFood int
+	// Forbidden places a forbidden food on the board which ends the
+	// game when eaten. It moves to another cell periodically.
+	Forbidden bool
+	// ReachableFirstFood places the first food only on cells the snake
+	// can reach without crossing its body.
+	ReachableFirstFood bool
+	// Assist places all food on reachable cells.
+	Assist bool
+	// Minimap shows the whole board in a corner of the screen. Boards
+	// larger than the screen always show it.
+	Minimap bool
+	// Background is the path of a PNG image drawn behind the board.
+	Background string
+	// SlowMotion stretches the next step when the snake is about to
+	// run into something, giving the player time to react.
+	SlowMotion bool
+	// Segmented draws the body cells with a small gap between them.
+	Segmented bool
+	// Shapes tells the items on the board apart by shape as well as by
+	// color: food is drawn as circles, diamonds and rings, poison as a
+	// cross and the snakes as squares.
+	Shapes bool
+	// Combo multiplies the points of food eaten within three seconds
+	// of the previous one, by up to five.
+	Combo bool
+	// Smooth draws the snakes gliding from cell to cell instead of
+	// jumping a cell every step. Drawn snakes trail the rules by up to
+	// one step.
+	Smooth bool
+	// Theme are the colors the game is drawn in.
+	Theme Theme
+	// HighScoreFile is the path of the high score table. With an empty
+	// path the table is kept in memory only.
+	HighScoreFile string
+	// Leaderboard is the URL of an online leaderboard the scores are
+	// posted to. Empty keeps the scores offline.
+	Leaderboard string
+	// Connect is the WebSocket URL of a netplay server. If set, runs are
+	// matches against another player on the server.
+	Connect string
+	// OnGameOver is called with the stats of the run when the snake
+	// died.
+	OnGameOver func(Stats) `json:"-"`
+	// OnReplay is called with the recording of a run when it ended.
+	OnReplay func(*Replay) `json:"-"`
+	// Ghost races the player against a translucent snake playing back
+	// their best run in the current mode. Ghosts are not shown in
+	// two-player games.
+	Ghost bool
+	// IdlePause pauses a run once the player gave no input for this
+	// long, as if they walked away. With 0 runs are never paused for it.
+	IdlePause time.Duration
+	// RestartTips shows a tip with the controls after a few restarts
+	// in quick succession.
+	RestartTips bool
+	// AutoRestart starts a new game this long after the game is over,
+	// for kiosks and demos. The menu key still leaves for the menu
+	// before. With 0 the game waits for the restart key.
+	AutoRestart time.Duration
+	// Replay is a recorded run to play back instead of taking input.
+	// Its rules replace the options setting the rules of a run, see
+	// game.Rules.
+	Replay *Replay `json:"-"`
+	// CRT draws the screen with scanlines and a vignette.
+	CRT bool
+	// IntegerScale keeps the screen at Width by Height pixels and scales
+	// it up by the largest whole factor fitting the window or monitor,
+	// with bars around it, so the tiles stay crisp. Otherwise the board
+	// is laid out anew for every size of the screen.
+	IntegerScale bool
+	// Debug enables the debug overlay toggled with F3, showing the
+	// frame and tick rates, the snake and the food, and the grid lines
+	// toggled with F4.
+	Debug bool
+	// Sound enables the music and sound effects. They can be muted in
+	// game with M, + and - change the music volume, or the effects
+	// volume while Shift is held.
+	Sound bool
+	// Keys maps the actions to keys. Actions missing from the map have
+	// no key.
+	Keys Keymap
+	// OnKeymapChange is called with the new keymap after the player
+	// changed the controls in game.
+	OnKeymapChange func(Keymap) `json:"-"`
+	// OnOptionsChange is called with the options after the player left
+	// the settings screen.
+	OnOptionsChange func(Options) `json:"-"`
+	// TouchButtons shows on-screen arrow buttons for touch screens.
+	// Swipes steer the snake either way.
+	TouchButtons bool
+	// DeadZone is the share of the analog stick range around the
+	// center which is ignored, from 0 to 1.
+	DeadZone float64
+	// VolumeFile is the path of the volume settings. With an empty path
+	// volume changes are not kept.
+	VolumeFile string
+	// SaveFile is the path of the quicksave. With an empty path the
+	// save is kept in memory.
+	SaveFile string
+	// LifetimeFile is the path of the statistics of all runs. With an
+	// empty path they only count the runs of this session.
+	LifetimeFile string
+	// StreakScore is the score a run needs for the streak of runs in a
+	// row shown on the menu, DefaultStreakScore if 0.
+	StreakScore int64
+	// AchievementsFile is the path of the achievements unlocked. With an
+	// empty path they are only kept for this session.
+	AchievementsFile string
+}
+
+// Validate reports whether the options describe a playable board.
+func (o Options) Validate() error {
+	if o.Level != nil {
+		o.CellsX, o.CellsY = o.Level.Size()
+	}
+	if o.Width < 1 || o.Height < 1 {
+		return fmt.Errorf("invalid screen size %dx%d", o.Width, o.Height)
+	}
+	if o.CellsX < 1 || o.CellsY < 1 {
+		return fmt.Errorf("invalid board size %dx%d", o.CellsX, o.CellsY)
+	}
+	if o.CellSize < 0 {
+		return fmt.Errorf("invalid cell size %d", o.CellSize)
+	}
+	if o.TickRate < 0 {
+		return fmt.Errorf("invalid tick rate %d", o.TickRate)
+	}
+	if o.DeadZone < 0 || o.DeadZone >= 1 {
+		return fmt.Errorf("invalid dead zone %v, must be from 0 to below 1", o.DeadZone)
+	}
+	if o.InitialLength < 1 || o.InitialLength > o.CellsX+1 {
+		return fmt.Errorf("initial length %d does not fit into %d columns", o.InitialLength, o.CellsX+1)
+	}
+	free := 0
+	for y := 0; y <= o.CellsY; y++ {
+		for x := 0; x <= o.CellsX; x++ {
+			if o.Level == nil || o.Level.Playable(x, y) {
+				free++
+			}
+		}
+	}
+	free -= o.InitialLength + o.Obstacles
+	if o.TwoPlayer || o.Coop || o.AI {
+		free -= o.InitialLength
+	}
+	if o.Harvest > free {
+		return fmt.Errorf("harvest waves of %d food do not fit into the %d free cells", o.Harvest, free)
+	}
+	return nil
+}
+
+// Rules returns the rules of a run with these options for frontends
+// other than Game. Campaigns load the built-in campaign levels.
+func (o Options) Rules() (game.Rules, error) {
+	var campaign []*game.Level
+	if o.Campaign {
+		var err error
+		if campaign, err = game.CampaignLevels(); err != nil {
+			return game.Rules{}, err
+		}
+	}
+	return o.rules(campaign), nil
+}
+
+// rules returns the rules of a run with these options, playing through
+// the campaign levels in a campaign.
+func (o Options) rules(campaign []*game.Level) game.Rules {
+	r := game.Rules{
+		CellsX:             o.CellsX,
+		CellsY:             o.CellsY,
+		InitialLength:      o.InitialLength,
+		Speed:              o.Speed,
+		SpeedUp:            o.SpeedUp,
+		Chaos:              o.Chaos,
+		Harvest:            o.Harvest,
+		Walls:              o.Walls,
+		Level:              o.Level,
+		TwoPlayer:          o.TwoPlayer,
+		LongerWins:         o.LongerWins,
+		Coop:               o.Coop,
+		Shrink:             o.Shrink,
+		Gauntlet:           o.Gauntlet,
+		AI:                 o.AI,
+		Bot:                o.Bot,
+		Autopilot:          o.Autopilot,
+		Tron:               o.Tron,
+		Powerups:           o.Powerups,
+		Poison:             o.Poison,
+		Obstacles:          o.Obstacles,
+		WrapGrace:          o.WrapGrace,
+		GrowPerFood:        o.GrowPerFood,
+		Forbidden:          o.Forbidden,
+		ReachableFirstFood: o.ReachableFirstFood,
+		Assist:             o.Assist,
+		SlowMotion:         o.SlowMotion,
+		Combo:              o.Combo,
+	}
+	if o.Campaign {
+		r.Campaign = campaign
+	}
+	return r
+}
+
+// setRules sets the options setting the rules of a run to r, for
+// playing back a run with these rules. The turns of the run are played
+// back, so the autopilot is off.
+func (o *Options) setRules(r game.Rules) {
+	o.CellsX, o.CellsY = r.CellsX, r.CellsY
+	o.InitialLength = r.InitialLength
+	o.Speed, o.SpeedUp = r.Speed, r.SpeedUp
+	o.Chaos = r.Chaos
+	o.Harvest = r.Harvest
+	o.Walls = r.Walls
+	o.Level = r.Level
+	o.Campaign = len(r.Campaign) > 0
+	o.TwoPlayer, o.Coop, o.Shrink = r.TwoPlayer, r.Coop, r.Shrink
+	o.LongerWins = r.LongerWins
+	o.Gauntlet = r.Gauntlet
+	o.AI = r.AI
+	o.Autopilot = nil
+	o.Tron = r.Tron
+	o.Powerups, o.Poison = r.Powerups, r.Poison
+	o.Obstacles = r.Obstacles
+	o.WrapGrace = r.WrapGrace
+	o.GrowPerFood = r.GrowPerFood
+	o.Forbidden = r.Forbidden
+	o.ReachableFirstFood = r.ReachableFirstFood
+	o.Assist = r.Assist
+	o.SlowMotion = r.SlowMotion
+	o.Combo = r.Combo
+}
+
+// DefaultOptions returns the options of the classic game.
+func DefaultOptions() Options {
+	return Options{
+		Width:         500,
+		Height:        400,
+		CellsX:        60,
+		CellsY:        40,
+		Filter:        ebiten.FilterNearest,
+		InitialLength: 3,
+		Speed:         12.0,
+		SpeedUp:       10000.0,
+		Theme:         DefaultTheme,
+		HighScoreFile: DefaultHighScoreFile(),
+		Keys:          DefaultKeymap(),
+		Sound:         true,
+		Smooth:        true,
+		DeadZone:      0.5,
+		// phones and tablets only run the browser build
+		TouchButtons:     runtime.GOOS == "js",
+		VolumeFile:       audio.DefaultVolumeFile(),
+		SaveFile:         DefaultSaveFile(),
+		LifetimeFile:     DefaultLifetimeFile(),
+		StreakScore:      DefaultStreakScore,
+		IdlePause:        DefaultIdlePause,
+		RestartTips:      true,
+		AchievementsFile: DefaultAchievementsFile(),
+	}
+}
+
+// GameState is the state a game is in.
+type GameState int
+
+const (
+	// StateMenu is the title screen waiting for the player to start.
+	StateMenu GameState = iota
+	// StatePlaying is the state of a running game.
+	StatePlaying
+	// StatePaused is a running game on hold.
+	StatePaused
+	// StateGameOver is the state after the snake died. The game waits
+	// for the player to restart.
+	StateGameOver
+	// StateNameEntry asks the player for their initials after a new
+	// high score.
+	StateNameEntry
+	// StateControls lets the player change the keys.
+	StateControls
+	// StateLevelComplete is the break between two campaign levels.
+	StateLevelComplete
+	// StateDemo is a game on autopilot started after idling on the
+	// menu. Any input returns to the menu.
+	StateDemo
+	// StateEnded is the state after the player quit.
+	StateEnded
+	// StateCrashed is the moment after a crash, shown in slow motion
+	// with visual effects enabled, before the game over screen.
+	StateCrashed
+	// StateSettings lets the player change the options.
+	StateSettings
+	// StateStatistics shows the lifetime statistics.
+	StateStatistics
+	// StateAchievements shows the achievements unlocked so far.
+	StateAchievements
+	// StateLobby connects to the netplay server and lets the player
+	// create or join a room and get ready for a match.
+	StateLobby
+)
+
+// Game is a running game of snake.
+type Game struct {
+	options Options
+	state   GameState
+
+	// sim is the run played
+	sim *game.Game
+	w   *world
+	// anims are the animated elements of the interface
+	anims animations
+	// particles are the particles of the effects
+	particles *particles
+	// crashLeft counts down the frames of StateCrashed, shake and flash
+	// the frames the board keeps shaking and the screen flashing.
+	// flashImg is the flash tint.
+	crashLeft int
+	shake     int
+	flash     int
+	flashImg  *ebiten.Image
+
+	// campaign are the campaign levels, nil outside of campaigns
+	campaign []*game.Level
+
+	// idle counts the frames without input on the menu. While demo is
+	// set a demo game runs with the options of the player kept in
+	// menuOptions.
+	idle        int
+	demo        bool
+	menuOptions Options
+
+	// seed is the seed of the current run, 0 for a restored run which
+	// no seed plays again
+	seed int64
+	// replay is the run played back, nil if the players are in control.
+	// replayAt is the index of its next turn. recording is the replay
+	// of the current run and ended the one of the run ended last.
+	replay    *Replay
+	replayAt  int
+	scrub     scrubber
+	recording *Replay
+	ended     *Replay
+	// best is the replay of the best run in the current mode, nil if
+	// there is none. ghost plays it back during a run if enabled, its
+	// next turn is best.Turns[ghostAt].
+	best    *Replay
+	ghost   *game.Game
+	ghostAt int
+	// touchTurn is the direction swiped or tapped since the last tick,
+	// -1 if there is none
+	touchTurn int
+	// clock hands out the ticks of the run
+	clock clock
+
+	powerupTiles []*ebiten.Image
+
+	crt     *crt
+	minimap *minimap
+	touches *touches
+	// sound is nil without sound
+	sound *audio.Player
+	// volumeShown is the number of frames the volume stays on screen
+	volumeShown int
+	// message is a notice shown for messageShown more frames
+	message      string
+	messageShown int
+	// saved is the last quicksave, nil if there is none
+	saved *savedGame
+
+	scores highScores
+	// leaderboard is the online leaderboard, nil if there is none.
+	// global is its answer to the last score, globalDone receives it
+	// while it is awaited.
+	leaderboard *leaderboard.Client
+	global      *globalScores
+	globalDone  chan globalScores
+	// net is the connection to the netplay server, nil if there is
+	// none, netDialed receives it while connecting. netPlayer is the
+	// player in the room, netTurn the last direction sent, netStatus the
+	// state of the connection or the last error of the server and
+	// netResult the result of the last match.
+	net       *netplay.Client
+	netDialed chan dialed
+	netPlayer int
+	netTurn   int
+	netStatus string
+	netResult string
+	// room is the room joined, nil outside of one. roomCode is the code
+	// typed to join a room and roomEntry the setting chosen by player
+	// one.
+	room      *netplay.Room
+	roomCode  string
+	roomEntry int
+	// pred predicts the online match played. Spectators look at
+	// watchX, watchY or follow the player watchFollow.
+	pred           prediction
+	watchX, watchY float64
+	watchFollow    int
+	// chatOpen is set while typing the chat message chatText, chatLog
+	// are the chat messages shown
+	chatOpen bool
+	chatText string
+	chatLog  []chatLine
+	// lifetime are the statistics of all runs
+	lifetime Lifetime
+	// unlocked are the unlock times of the achievements by id, run the
+	// progress of this run towards them and toasts the names of the
+	// ones unlocked still to show, the first one for toastShown frames
+	unlocked   map[string]time.Time
+	run        runProgress
+	toasts     []string
+	toastShown int
+	// overFrames is the number of frames the game over screen is shown
+	overFrames int
+	// idleFrames is the number of frames played without input
+	idleFrames int
+	// restarts are the times of the last restarts from the game over
+	// screen, see noteRestart
+	restarts []time.Time
+	// name is the initials entered for a new high score
+	name string
+	// controls is the action selected on the controls screen, rebinding
+	// is set while waiting for its new key and controlsFrom is the state
+	// the screen returns to
+	controls     Action
+	rebinding    bool
+	controlsFrom GameState
+	// pauseEntry is the entry selected on the pause menu
+	pauseEntry int
+	// settingsEntry is the entry selected on the settings screen and
+	// settingsFrom the state the screen returns to
+	settingsEntry int
+	settingsFrom  GameState
+	// pending are the walls and the speed changed for the next run
+	pending pendingRules
+	// countdown is the number of frames left before the snake moves
+	countdown int
+	// fullscreen is set while the game is shown in fullscreen and
+	// windowW and windowH are the size of the screen in the window
+	fullscreen       bool
+	windowW, windowH int
+	// scaled is the screen drawn to with IntegerScale
+	scaled *ebiten.Image
+	debug  debug
+	// gif records the screen, nil while not recording. gifSaved
+	// receives a message once a recording was saved.
+	gif      *gifRecorder
+	gifSaved chan string
+
+	// face is the font of the HUD sized for the screen, hudScale the
+	// number of device pixels per screen pixel it is sized with
+	face     font.Face
+	hudScale float64
+	textImg  *ebiten.Image
+	overlay  *ebiten.Image
+}
+
+// NewGame creates a game ready to be driven by Update. It fails if the
+// options, or the rules of the replay, do not pass Validate.
+func NewGame(o Options) (*Game, error) {
+	if r := o.Replay; r != nil {
+		o.setRules(r.Rules)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if o.Speed < MinSpeed {
+		o.Speed = MinSpeed
+	}
+	if o.Theme == (Theme{}) {
+		o.Theme = DefaultTheme
+	}
+	if o.TwoPlayer {
+		o.Coop = false
+	}
+	if o.TwoPlayer || o.Coop || o.Tron || o.Shrink {
+		o.Campaign = false
+	}
+	if o.TwoPlayer || o.Coop {
+		o.AI = false
+	}
+	if o.Coop {
+		o.Tron = false
+	}
+	var campaign []*game.Level
+	if o.Campaign {
+		var err error
+		if campaign, err = game.CampaignLevels(); err != nil || len(campaign) == 0 {
+			log.Printf("could not load the campaign: %v", err)
+			o.Campaign = false
+		} else {
+			o.Level = campaign[0]
+		}
+	}
+	if o.Level != nil {
+		o.CellsX, o.CellsY = o.Level.Size()
+	}
+	if o.Keys == nil {
+		o.Keys = DefaultKeymap()
+	}
+	g := &Game{
+		options:   o,
+		campaign:  campaign,
+		replay:    o.Replay,
+		touchTurn: -1,
+		clock:     newClock(o.TickRate),
+		particles: newParticles(),
+		gifSaved:  make(chan string, 1),
+	}
+	g.hudScale = hudScale(o.Scale)
+	g.face = newHUDFace(o.Height, g.hudScale)
+	g.initWorld()
+	g.loadScores()
+	if o.CRT {
+		g.crt = newCRT(o.Width, o.Height)
+	}
+	g.touches = newTouches(o.Width, o.Height, o.TouchButtons)
+	if o.Sound {
+		g.startSound()
+	}
+	if o.LifetimeFile != "" {
+		l, err := loadLifetime(o.LifetimeFile)
+		if err != nil {
+			log.Printf("could not load statistics: %v", err)
+		}
+		g.lifetime = l
+	}
+	if o.Leaderboard != "" {
+		g.leaderboard = leaderboard.NewClient(o.Leaderboard)
+	}
+	g.unlocked = make(map[string]time.Time)
+	if o.AchievementsFile != "" {
+		u, err := loadAchievements(o.AchievementsFile)
+		if err != nil {
+			log.Printf("could not load achievements: %v", err)
+		}
+		g.unlocked = u
+	}
+	if o.SaveFile != "" {
+		sv, err := loadSave(o.SaveFile)
+		if err != nil {
+			log.Printf("could not load the saved game: %v", err)
+		}
+		g.saved = sv
+	}
+	g.Reset()
+	g.state = StateMenu
+	if g.replay != nil {
+		g.state = StatePlaying
+	}
+	if o.Connect != "" {
+		g.openLobby()
+	}
+	return g, nil
+}
+
+// initWorld creates the board and the tiles drawn in the colors of the
+// theme.
+func (g *Game) initWorld() {
+	o := g.options
+	g.w = newWorld(o.Width, o.Height, o.CellsX, o.CellsY, o.CellSize, o.Filter, o.Theme, o.Shapes)
+	g.w.segmented = o.Segmented
+	if o.Background != "" {
+		if err := g.w.loadBackground(o.Background); err != nil {
+			log.Printf("could not load background, using plain color: %v", err)
+		}
+	}
+	g.minimap = nil
+	if o.Minimap || g.w.scrolls() {
+		g.minimap = newMinimap(g.w)
+	}
+	g.powerupTiles = nil
+	if o.Powerups {
+		g.powerupTiles = powerupTiles(g.w)
+	}
+	g.overlay = nil
+	if g.sim != nil {
+		h := g.sim.Snake().Head()
+		g.w.follow(h.X, h.Y)
+	}
+}
+
+// Resize adapts the game to a screen of width by height pixels: the
+// cells, the tiles and the HUD font are sized anew and boards fitting
+// into the window are centered. Update calls it when the size of the
+// screen changed.
+func (g *Game) Resize(width, height int) {
+	if width == g.options.Width && height == g.options.Height {
+		return
+	}
+	g.options.Width, g.options.Height = width, height
+	g.hudScale = hudScale(g.options.Scale)
+	g.face = newHUDFace(height, g.hudScale)
+	g.textImg = nil
+	g.flashImg = nil
+	g.initWorld()
+	if g.crt != nil {
+		g.crt = newCRT(width, height)
+	}
+	g.touches = newTouches(width, height, g.options.TouchButtons)
+}
+
+// Reset starts a new run with a new snake and food, resetting score
+// and speed to the initial options. Restarting from the game over
+// screen calls it, as may games embedding the package.
+func (g *Game) Reset() {
+	g.applyPending()
+	g.state = StatePlaying
+	g.overFrames, g.idleFrames = 0, 0
+	g.seed = g.seedRun()
+	g.replayAt = 0
+	g.recording = nil
+	if g.replay == nil {
+		g.recording = &Replay{Rules: g.options.rules(g.campaign), Seed: g.seed}
+	}
+	g.sim = game.New(g.options.rules(g.campaign), g.seed)
+	g.anims.clear()
+	g.particles.clear()
+	g.shake, g.flash = 0, 0
+	g.touchTurn = -1
+	h := g.sim.Snake().Head()
+	g.w.follow(h.X, h.Y)
+	g.global, g.globalDone = nil, nil
+	g.startGhost()
+	g.startAchievements()
+	g.startCountdown()
+}
+
+// autoRestartIn returns the number of frames until the game restarts
+// on its own, -1 without Options.AutoRestart.
+func (g *Game) autoRestartIn() int {
+	if g.options.AutoRestart <= 0 {
+		return -1
+	}
+	left := int(g.options.AutoRestart*DefaultTickRate/time.Second) - g.overFrames
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+const (
+	// rapidRestarts is the number of restarts within rapidWindow after
+	// which a tip is shown.
+	rapidRestarts = 3
+	rapidWindow   = 20 * time.Second
+)
+
+// noteRestart counts a restart by the player at now. After
+// rapidRestarts of them within rapidWindow, dying again and again right
+// away, it reminds them of the controls with Options.RestartTips.
+func (g *Game) noteRestart(now time.Time) {
+	if !g.options.RestartTips {
+		return
+	}
+	recent := g.restarts[:0]
+	for _, t := range g.restarts {
+		if now.Sub(t) < rapidWindow {
+			recent = append(recent, t)
+		}
+	}
+	g.restarts = append(recent, now)
+	if len(g.restarts) < rapidRestarts {
+		return
+	}
+	g.restarts = g.restarts[:0]
+	g.showMessage(fmt.Sprintf("take a breath - %s pauses, C on the menu shows the controls", g.options.Keys.keyNames(ActionPause)))
+}
+
+// nextLevel starts the next campaign level, keeping the score.
+func (g *Game) nextLevel() {
+	g.sim.NextLevel()
+	g.anims.clear()
+	g.state = StatePlaying
+	g.startCountdown()
+}
+
+// finish ends the run, asking for initials on a new high score.
+func (g *Game) finish() {
+	if g.demo {
+		g.state = StateGameOver
+		return
+	}
+	g.state = StateGameOver
+	// online scores need a name even if they do not make the local table
+	if g.options.Autopilot == nil && g.replay == nil && (g.scores.qualifies(g.sim.Points()) || g.leaderboard != nil) {
+		g.state = StateNameEntry
+		g.name = ""
+	}
+	g.recordLifetime()
+	if g.options.OnGameOver != nil {
+		g.options.OnGameOver(g.Stats())
+	}
+	g.endRecording()
+}
+
+// maxNameLen is the number of initials entered for a high score.
+const maxNameLen = 3
+
+// updateNameEntry reads the initials for a new high score and stores
+// the score once confirmed, posting it to the online leaderboard.
+func (g *Game) updateNameEntry(enter bool) {
+	for _, r := range ebiten.InputChars() {
+		if len(g.name) >= maxNameLen {
+			break
+		}
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			g.name += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.name) > 0 {
+		g.name = g.name[:len(g.name)-1]
+	}
+	if !enter {
+		return
+	}
+	name := g.name
+	if name == "" {
+		name = "???"
+	}
+	if g.scores.qualifies(g.sim.Points()) {
+		g.scores = g.scores.insert(HighScore{Name: name, Score: g.sim.Points(), Date: time.Now()})
+		if path := g.scoreFile(); path != "" {
+			if err := g.scores.save(path); err != nil {
+				log.Printf("could not save high scores: %v", err)
+			}
+		}
+	}
+	g.submitScore(name)
+	g.state = StateGameOver
+}
+
+// Layout returns the screen size the game renders at.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.options.Width, g.options.Height
+}
+
+// control returns the turn of player one for a direction from the
+// controls. While the controls are reversed the direction is inverted.
+func (g *Game) control(direction int) game.Turn {
+	if g.sim.Reversed() {
+		direction = (direction + 2) % 4
+	}
+	return game.Turn{Direction: direction}
+}
+
+// Update advances the game by the ticks due since the last call and
+// draws it to screen. It has the signature of the update function
+// passed to ebiten.Run.
+func (g *Game) Update(screen *ebiten.Image) error {
+	if !g.options.IntegerScale {
+		g.Resize(screen.Size())
+	}
+	g.updateFullscreen()
+	g.updateDebug()
+	keys := g.options.Keys
+	back := keys.justPressed(ActionQuit)
+	start := gamepadJustPressed(gamepadStart)
+	direction, turned, tapped := g.touches.update(g.w.screenH)
+	enter := keys.justPressed(ActionRestart) || start || gamepadJustPressed(gamepadA) || tapped
+	if g.state != StateNameEntry && g.state != StateControls && g.state != StateSettings {
+		g.updateVolume()
+	}
+	if g.messageShown > 0 {
+		g.messageShown--
+	}
+	g.updateToasts()
+	g.updateChatLog()
+	g.updateLeaderboard()
+	g.updateNet()
+	if g.replay == nil && g.options.Connect == "" && g.state != StateNameEntry && g.state != StateControls && g.state != StateSettings && g.state != StateDemo && g.state != StateCrashed && keys.justPressed(ActionLoad) && g.quickload() {
+		return nil
+	}
+	switch g.state {
+	case StateMenu:
+		g.applyPending()
+		if back {
+			g.state = StateEnded
+			return ErrEnd
+		}
+		if enter {
+			g.restart()
+		}
+		if g.replay != nil {
+			// the options of a replay are fixed
+			break
+		}
+		g.updateIdle(anyInput())
+		if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+			g.state = StateControls
+			g.controls = ActionUp
+			g.controlsFrom = StateMenu
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			g.openSettings(StateMenu)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyI) {
+			g.state = StateStatistics
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+			g.state = StateAchievements
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key2) {
+			g.cyclePlayers()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyT) && !g.options.Campaign && !g.options.Coop {
+			g.options.Tron = !g.options.Tron
+			g.loadScores()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyB) && !g.options.Campaign {
+			g.options.Shrink = !g.options.Shrink
+			g.loadScores()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyG) {
+			if g.options.Gauntlet > 0 {
+				g.options.Gauntlet = 0
+			} else {
+				g.options.Gauntlet = game.DefaultGauntlet
+			}
+			g.loadScores()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+			g.options.Daily = !g.options.Daily
+			g.loadScores()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+			g.options.Shapes = !g.options.Shapes
+			g.initWorld()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+			g.options.Theme = nextTheme(g.options.Theme)
+			g.initWorld()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+			g.options.Walls = !g.options.Walls
+			g.loadScores()
+		}
+	case StatePlaying:
+		if g.net != nil && g.updateChat(back, enter) {
+			// the chat takes the keys
+			back = false
+		}
+		if g.net != nil && back {
+			// leaving gives up the match
+			g.leaveRoom()
+			break
+		}
+		if (back || start || keys.justPressed(ActionPause)) && g.net == nil {
+			g.pause()
+			break
+		}
+		if keys.justPressed(ActionSave) && g.replay == nil && g.net == nil {
+			g.quicksave()
+		}
+		if g.spectating() {
+			g.updateCamera()
+		}
+		if turned {
+			g.touchTurn = direction
+		}
+		if g.countdown > 0 {
+			g.countdown--
+			break
+		}
+		if g.idleTooLong() {
+			g.pause()
+			g.showMessage("paused - no input for a while")
+			break
+		}
+		if g.replay != nil {
+			g.updateScrubber()
+		}
+		g.advance()
+	case StatePaused:
+		g.updatePaused(back, enter)
+	case StateGameOver:
+		if g.net != nil {
+			g.updateResults(back, enter)
+			break
+		}
+		g.overFrames++
+		if back {
+			g.state = StateMenu
+		} else if g.replay != nil && inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+			// step back into the end of the replay
+			g.scrub.paused = true
+			g.seekReplay(g.sim.Frame() - 1)
+		} else if enter {
+			g.restart()
+			g.noteRestart(time.Now())
+		} else if g.autoRestartIn() == 0 {
+			g.restart()
+		}
+	case StateNameEntry:
+		g.updateNameEntry(enter)
+	case StateControls:
+		g.updateControls()
+	case StateSettings:
+		g.updateSettings()
+	case StateStatistics:
+		g.updateStatistics(back, enter)
+	case StateAchievements:
+		g.updateAchievements(back, enter)
+	case StateLobby:
+		g.updateLobby(back, enter)
+	case StateLevelComplete:
+		if back {
+			g.state = StateMenu
+		}
+		if enter {
+			g.nextLevel()
+		}
+	case StateDemo:
+		g.updateDemo(anyInput())
+	case StateCrashed:
+		g.updateCrash(enter || back)
+	}
+	if g.state != StatePlaying && g.state != StateDemo || g.countdown > 0 {
+		g.clock.stop()
+	}
+	if g.options.Effects && !g.slowMotion() {
+		g.particles.update()
+		g.anims.update()
+	}
+	if g.shake > 0 {
+		g.shake--
+	}
+	if g.flash > 0 {
+		g.flash--
+	}
+
+	if ebiten.IsRunningSlowly() {
+		// frame skip, the clock catches up with the ticks missed
+		return nil
+	}
+	g.debug.draws++
+	out := screen
+	if g.options.IntegerScale {
+		if g.scaled == nil {
+			g.scaled, _ = ebiten.NewImage(g.options.Width, g.options.Height, ebiten.FilterNearest)
+		}
+		out = g.scaled
+	}
+	if g.crt != nil {
+		g.draw(g.crt.canvas)
+		g.crt.draw(out)
+	} else {
+		g.draw(out)
+	}
+	if out != screen {
+		g.drawScaled(screen, out)
+	}
+	g.updateScreenshot(screen)
+	g.updateGIF(screen)
+
+	return nil
+}
+
+// volumeLife is the number of frames the volume is shown after a change.
+const volumeLife = 90
+
+// startSound starts the music and the sound effects at the volume kept
+// in Options.VolumeFile.
+func (g *Game) startSound() {
+	v := audio.DefaultVolume
+	if g.options.VolumeFile != "" {
+		var err error
+		if v, err = audio.LoadVolume(g.options.VolumeFile); err != nil {
+			log.Printf("could not load volume: %v", err)
+		}
+	}
+	p, err := audio.NewPlayer(v)
+	if err != nil {
+		log.Printf("could not initialize sound: %v", err)
+	}
+	g.sound = p
+}
+
+// updateVolume handles the mute and volume keys.
+func (g *Game) updateVolume() {
+	if g.volumeShown > 0 {
+		g.volumeShown--
+	}
+	if g.sound == nil {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.sound.ToggleMute()
+	}
+	step := 0.0
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyKPAdd) {
+		step = audio.VolumeStep
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyKPSubtract) {
+		step = -audio.VolumeStep
+	}
+	if step == 0 {
+		return
+	}
+	v := g.sound.Volume()
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		v.Effects += step
+	} else {
+		v.Music += step
+	}
+	g.sound.SetVolume(v)
+	g.volumeShown = volumeLife
+	g.saveVolume()
+}
+
+// update advances a running game by one frame.
+func (g *Game) update() {
+	g.updateGhost()
+	var turns []game.Turn
+	switch {
+	case g.replay != nil:
+		turns = replayTurns(g.replay, &g.replayAt, g.sim)
+	case (g.options.TwoPlayer || g.options.Coop) && g.net == nil:
+		turns = g.playerTurns()
+	case g.chatOpen:
+		// the keys type the chat message
+	case g.options.Autopilot == nil:
+		for _, st := range steering {
+			if g.options.Keys.pressed(st.action) {
+				turns = append(turns, g.control(st.direction))
+			}
+		}
+	}
+	if g.replay == nil && g.options.Autopilot == nil {
+		if g.touchTurn != -1 {
+			turns = append(turns, g.control(g.touchTurn))
+			g.touchTurn = -1
+		}
+		if direction, ok := gamepadDirection(g.options.DeadZone); ok {
+			turns = append(turns, g.control(direction))
+		}
+	}
+	if g.spectating() {
+		// the camera moves in updateCamera
+		g.predict()
+		return
+	}
+	if g.net != nil {
+		// the server runs the match, the local run predicts it
+		g.sendTurns(turns)
+		g.predict()
+		h := g.ownSnake().Head()
+		g.w.follow(h.X, h.Y)
+		return
+	}
+	for _, e := range g.sim.Tick(turns...) {
+		g.trackAchievements(e)
+		g.handle(e)
+	}
+	g.checkAchievements()
+	h := g.sim.Snake().Head()
+	g.w.follow(h.X, h.Y)
+}
+
+// handle plays the sounds and shows the effects of an event of the run.
+func (g *Game) handle(e game.Event) {
+	switch e.Kind {
+	case game.EventTurn:
+		g.record(e)
+		if e.Player == 0 && g.options.Autopilot == nil {
+			g.sound.Play(audio.Turn)
+		}
+	case game.EventEat:
+		g.sound.Play(audio.Eat)
+		fallthrough
+	case game.EventPoison:
+		if g.options.Effects {
+			g.burstFood(e.X, e.Y, e.Food)
+			g.addPopup(e.X, e.Y, e.Points, e.Combo)
+		}
+	case game.EventBonus:
+		if g.options.Effects {
+			g.addPopup(e.X, e.Y, e.Points, 0)
+		}
+	case game.EventPowerup, game.EventMilestone:
+		g.sound.Play(audio.Milestone)
+	case game.EventLevelComplete:
+		g.sound.Play(audio.Milestone)
+		if g.sim.Won() {
+			g.finish()
+		} else {
+			g.state = StateLevelComplete
+		}
+	case game.EventShrink:
+		g.shrunk(e)
+	case game.EventGauntlet:
+		g.sound.Play(audio.Milestone)
+		g.showMessage("new obstacles in the way")
+	case game.EventLifeLost:
+		g.sound.Play(audio.Die)
+		g.showMessage(fmt.Sprintf("player %d crashed - %d lives left", e.Player+1, g.sim.Lives()))
+		if g.options.Effects {
+			g.burstCrash(e.X, e.Y, e.Player)
+		}
+	case game.EventGameOver:
+		g.sound.Play(audio.Die)
+		if g.options.Effects && !g.demo {
+			g.crash()
+			return
+		}
+		g.endRun()
+	}
+}
+
+// endRun ends the run after the snake crashed.
+func (g *Game) endRun() {
+	if g.options.TwoPlayer {
+		g.twoPlayerOver()
+	} else {
+		g.finish()
+	}
+}
+
+// formatScore formats points for the HUD, as seconds in tron games.
+func (g *Game) formatScore(points int64) string {
+	if g.options.Tron {
+		return fmt.Sprintf("%d.%ds", points/10, points%10)
+	}
+	return strconv.FormatInt(points, 10)
+}
+
+// draw draws the board, the snake and the HUD to canvas.
+func (g *Game) draw(canvas *ebiten.Image) {
+	w, sim := g.w, g.sim
+	canvas.Fill(w.theme.Background)
+	if s := g.ownSnake(); s != nil && w.scrolls() && g.state == StatePlaying && !g.spectating() {
+		w.glide(s, g.progress(sim))
+	}
+	// the board shakes after a crash, the HUD stays in place
+	dx, dy := g.shakeOffset()
+	w.camX, w.camY = w.camX+dx, w.camY+dy
+	w.draw(canvas)
+	w.drawObstacles(canvas, sim.Obstacles())
+	g.drawShrinkWarning(canvas)
+	g.drawGhost(canvas)
+	// crashed snakes are scattered into particles
+	for player, sk := range []*skin{w.snakeSkin, w.rivalSkin} {
+		s, in := g.shownSnake(player)
+		if s != nil && !(g.options.Effects && g.crashed(player)) {
+			w.drawSnake(canvas, s, sk, g.progress(in))
+		}
+	}
+	for _, f := range sim.Foods() {
+		w.drawFood(canvas, f)
+	}
+	if f := sim.Forbidden(); f != nil {
+		w.drawFoodTile(canvas, f, w.forbiddenTile)
+	}
+	for _, p := range sim.Poisons() {
+		w.drawFood(canvas, p)
+	}
+	g.drawPowerup(canvas)
+	if g.options.Effects {
+		g.particles.draw(canvas, w.camX, w.camY)
+		g.anims.draw(g, canvas)
+	}
+	w.camX, w.camY = w.camX-dx, w.camY-dy
+	g.drawFlash(canvas)
+	if g.minimap != nil {
+		g.drawMinimap(canvas)
+	}
+	if g.state == StatePlaying {
+		g.touches.draw(canvas)
+		g.drawCountdown(canvas)
+	}
+	g.drawHUD(canvas)
+	g.drawDebug(canvas)
+	g.drawChaos(canvas)
+	g.drawShrinkCountdown(canvas)
+	g.drawSpectating(canvas)
+	g.drawChat(canvas)
+	g.drawEffect(canvas)
+	g.drawVolume(canvas)
+	g.drawMessage(canvas)
+	g.drawToast(canvas)
+	switch g.state {
+	case StateMenu:
+		g.drawMenu(canvas)
+	case StatePaused:
+		g.drawPaused(canvas)
+	case StateGameOver:
+		g.drawGameOver(canvas)
+	case StateNameEntry:
+		g.drawNameEntry(canvas)
+	case StateControls:
+		g.drawControls(canvas)
+	case StateSettings:
+		g.drawSettings(canvas)
+	case StateStatistics:
+		g.drawStatistics(canvas)
+	case StateAchievements:
+		g.drawAchievements(canvas)
+	case StateLobby:
+		g.drawLobby(canvas)
+	case StateLevelComplete:
+		g.drawLevelComplete(canvas)
+	case StateDemo:
+		g.drawDemo(canvas)
+	}
+	if g.replay != nil && g.state == StatePlaying {
+		g.drawReplay(canvas)
+	}
+}
+
+// Score returns the current points.
+func (g *Game) Score() int64 {
+	return g.sim.Points()
+}
+
+// Length returns the number of cells the snake takes.
+func (g *Game) Length() int {
+	return g.sim.Snake().Len()
+}
+
+// SnakeCells returns the cells of the snake from head to tail.
+func (g *Game) SnakeCells() [][2]int {
+	s := g.sim.Snake()
+	cells := make([][2]int, 0, s.Len())
+	s.Each(func(c game.Cell) {
+		cells = append(cells, [2]int{c.X, c.Y})
+	})
+	return cells
+}
+
+// FoodCells returns the cells of the food on the board.
+func (g *Game) FoodCells() [][2]int {
+	foods := g.sim.Foods()
+	cells := make([][2]int, 0, len(foods))
+	for _, f := range foods {
+		if f.X < 0 {
+			continue
+		}
+		cells = append(cells, [2]int{f.X, f.Y})
+	}
+	return cells
+}
+
+// State returns the state the game is in.
+func (g *Game) State() GameState {
+	return g.state
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	Score     int64         `json:"score"`
+	MaxLength int           `json:"max_length"`
+	Eaten     int           `json:"food_eaten"`
+	Duration  time.Duration `json:"duration"`
+	// Seed is the seed the run was played with, also if Options.Seed
+	// is 0 and the run was seeded from the clock. It is 0 for restored
+	// runs.
+	Seed    int64     `json:"seed"`
+	Options Options   `json:"options"`
+	Time    time.Time `json:"time"`
+}
+
+// Stats returns the statistics of the run so far.
+func (g *Game) Stats() Stats {
+	return Stats{
+		Score:     g.sim.Points(),
+		MaxLength: g.sim.MaxLength(),
+		Eaten:     g.sim.Eaten(),
+		Duration:  time.Duration(float64(g.sim.Frame()) / g.clock.rate() * float64(time.Second)),
+		Seed:      g.seed,
+		Options:   g.options,
+		Time:      time.Now(),
+	}
+}
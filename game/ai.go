@@ -0,0 +1,113 @@
+package game
+
+import (
+	"github.com/wongak/snake/bot"
+)
+
+// aiRespawnDelay is the number of frames before a crashed computer
+// snake comes back.
+const aiRespawnDelay = 3 * 60
+
+// snapshot returns the board as seen by the snake you, with the other
+// snake, if any.
+func (g *Game) snapshot(you, other *Snake) bot.GameState {
+	b := g.board
+	state := bot.GameState{
+		Width:     b.cellsX + 1,
+		Height:    b.cellsY + 1,
+		Walls:     b.walls,
+		Obstacles: make([]bot.Cell, len(b.obstacles.cells)),
+		You:       you.botSnake(),
+	}
+	for i, c := range b.obstacles.cells {
+		state.Obstacles[i] = bot.Cell{X: c.X, Y: c.Y}
+	}
+	if other != nil {
+		state.Others = []bot.Snake{other.botSnake()}
+	}
+	// items without a free cell left are parked off the board
+	for _, f := range g.foods {
+		if f.X >= 0 && f.Y >= 0 {
+			state.Food = append(state.Food, bot.Cell{X: f.X, Y: f.Y})
+		}
+	}
+	for _, p := range g.poisons {
+		if p.X >= 0 && p.Y >= 0 {
+			state.Hazards = append(state.Hazards, bot.Cell{X: p.X, Y: p.Y})
+		}
+	}
+	if g.forbidden != nil && g.forbidden.X >= 0 && g.forbidden.Y >= 0 {
+		state.Hazards = append(state.Hazards, bot.Cell{X: g.forbidden.X, Y: g.forbidden.Y})
+	}
+	return state
+}
+
+// botSnake returns the snake as seen by bots.
+func (s *Snake) botSnake() bot.Snake {
+	b := bot.Snake{
+		Body:      make([]bot.Cell, 0, s.Len()),
+		Direction: bot.Direction(s.direction),
+	}
+	s.Each(func(c Cell) {
+		b.Body = append(b.Body, bot.Cell{X: c.X, Y: c.Y})
+	})
+	return b
+}
+
+// botMove asks b for the next direction of s. Unknown directions are
+// ignored.
+func (g *Game) botMove(b bot.Bot, s, other *Snake) (int, bool) {
+	d := b.NextMove(g.snapshot(s, other))
+	return int(d), d >= bot.Right && d <= bot.Up
+}
+
+// spawnAI places the computer snake away from the player's head. If the
+// board is too crowded it tries again on the next frame.
+func (g *Game) spawnAI() {
+	if g.rival = g.placeSnake(g.s); g.rival == nil {
+		g.aiRespawn = 1
+	}
+}
+
+// placeSnake returns a new snake on a random free row segment away from
+// the head of other, nil if the board is too crowded to find one.
+func (g *Game) placeSnake(other *Snake) *Snake {
+	b := g.board
+	n := g.rules.InitialLength
+	h := other.Head()
+	free := func(x, y int) bool {
+		return !b.obstacles.at(x, y) && !other.Occupies(x, y) && g.foodAt(x, y) == -1 && !g.forbiddenAt(x, y) && g.poisonAt(x, y) == -1
+	}
+	for try := 0; try < 100; try++ {
+		x, y := g.rng.Intn(b.cellsX+1), g.rng.Intn(b.cellsY+1)
+		if abs(x-h.X)+abs(y-h.Y) < b.cellsX/3 {
+			continue
+		}
+		ok := true
+		// the body extends to the left, keep some cells ahead free
+		for i := -n + 1; i <= spawnClearance && ok; i++ {
+			ok = free(((x+i)%(b.cellsX+1)+b.cellsX+1)%(b.cellsX+1), y)
+		}
+		if ok {
+			return newSnake(b, n, Cell{x, y})
+		}
+	}
+	return nil
+}
+
+// killAI removes the crashed computer snake until it respawns.
+func (g *Game) killAI() {
+	g.rival = nil
+	g.aiRespawn = aiRespawnDelay
+}
+
+// updateAI counts down the respawn of a crashed computer snake.
+func (g *Game) updateAI() {
+	if g.rival != nil {
+		return
+	}
+	g.aiRespawn--
+	if g.aiRespawn <= 0 {
+		g.spawnAI()
+	}
+}
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/wongak/snake/bot"
+)
+
+// TestSnapshotFullBoard parks the food, the poison and the forbidden
+// food off the board like a full board does and checks the bot does
+// not see them.
+func TestSnapshotFullBoard(t *testing.T) {
+	g := New(Rules{CellsX: 4, CellsY: 4, InitialLength: 3, Speed: 1, SpeedUp: 10000}, 1)
+	g.foods = []*Food{{X: -1, Y: -1, Kind: FoodTypes[0]}}
+	g.poisons = []*Food{{X: -1, Y: -1, Kind: Poison}}
+	g.forbidden = &Food{X: -1, Y: -1}
+	s := g.snapshot(g.s, nil)
+	if len(s.Food) != 0 || len(s.Hazards) != 0 {
+		t.Errorf("bot sees food %v and hazards %v off the board", s.Food, s.Hazards)
+	}
+	bot.Greedy{}.NextMove(s)
+}
+
+// TestAutopilotFullBoard lets the autopilot play on a tiny board until
+// the snake fills it up and the items are parked off the board.
+func TestAutopilotFullBoard(t *testing.T) {
+	rules := []Rules{
+		{},
+		{Harvest: 3, Poison: true, Forbidden: true, Assist: true, Powerups: true, Shrink: true},
+	}
+	for _, r := range rules {
+		r.CellsX, r.CellsY = 5, 5
+		r.InitialLength = 3
+		r.Speed, r.SpeedUp = 1, 10000
+		r.GrowPerFood = 3
+		r.Autopilot = bot.Greedy{}
+		for seed := int64(1); seed <= 20; seed++ {
+			g := New(r, seed)
+			for i := 0; i < 20000 && !g.Over(); i++ {
+				g.Tick()
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+//go:embed levels/campaign/*.txt
+var campaignFS embed.FS
+
+const (
+	// LevelFood is the number of food items completing a campaign level.
+	LevelFood = 10
+	// levelSpeedUp is the number of frames the step interval shortens
+	// with every campaign level.
+	levelSpeedUp = 1
+)
+
+// CampaignLevels loads the built-in campaign levels in order. All
+// levels have the same size, so the board can stay in place between
+// them.
+func CampaignLevels() ([]*Level, error) {
+	names, err := fs.Glob(campaignFS, "levels/campaign/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	levels := make([]*Level, 0, len(names))
+	for _, name := range names {
+		f, err := campaignFS.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		l, err := ParseLevel(name, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(levels) > 0 && (l.cellsX != levels[0].cellsX || l.cellsY != levels[0].cellsY) {
+			return nil, fmt.Errorf("%s: size differs from the first level", name)
+		}
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
+
+// completeLevel ends a campaign level after enough food was eaten. The
+// last level wins the campaign and ends the run.
+func (g *Game) completeLevel() {
+	if g.level+1 >= len(g.rules.Campaign) {
+		g.won = true
+		g.over = true
+	} else {
+		g.complete = true
+	}
+	g.emit(Event{Kind: EventLevelComplete})
+}
@@ -0,0 +1,37 @@
+package game
+
+// Cause is what ended a run.
+type Cause int
+
+const (
+	// CauseNone is a run still going, won or ended by the crash of the
+	// other snake.
+	CauseNone Cause = iota
+	// CauseWall is running into a solid board edge.
+	CauseWall
+	// CauseObstacle is running into a wall of the level or an obstacle.
+	CauseObstacle
+	// CauseSelf is running into the own body or trail.
+	CauseSelf
+	// CauseRival is running into the other snake.
+	CauseRival
+	// CauseForbidden is eating the forbidden food.
+	CauseForbidden
+	// CausePoison is poison eaten by a snake too short to lose
+	// segments.
+	CausePoison
+	// CauseArena is being caught by the closing walls of a shrinking
+	// arena.
+	CauseArena
+	// Causes is the number of causes.
+	Causes
+)
+
+var causeNames = [...]string{"none", "wall", "obstacle", "self", "rival", "forbidden", "poison", "arena"}
+
+func (c Cause) String() string {
+	if c < 0 || c >= Causes {
+		return "unknown"
+	}
+	return causeNames[c]
+}
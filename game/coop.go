@@ -0,0 +1,53 @@
+package game
+
+// DefaultLives is the number of shared lives of a co-op run unless
+// Rules.Lives is set.
+const DefaultLives = 3
+
+// coopCrash handles the crashes of the last step of a co-op run. A
+// crash into a wall, an obstacle, the snake itself or the other snake
+// costs one of the shared lives, even if both snakes crashed, and the
+// snakes which crashed start over on a free spot. coopCrash reports
+// whether the run ended.
+func (g *Game) coopCrash() bool {
+	one, two := g.crashCause(g.s, g.rival), g.crashCause(g.rival, g.s)
+	if one == CauseNone && two == CauseNone {
+		return false
+	}
+	cause := one
+	if cause == CauseNone {
+		cause = two
+	}
+	g.lives--
+	if g.lives <= 0 {
+		g.gameOver(cause)
+		return true
+	}
+	if one != CauseNone {
+		h := g.s.Head()
+		g.emit(Event{Kind: EventLifeLost, Player: 0, X: h.X, Y: h.Y})
+		s := g.placeSnake(g.rival)
+		if s == nil {
+			// no room left to start over
+			g.gameOver(cause)
+			return true
+		}
+		g.s = s
+	}
+	if two != CauseNone {
+		h := g.rival.Head()
+		g.emit(Event{Kind: EventLifeLost, Player: 1, X: h.X, Y: h.Y})
+		s := g.placeSnake(g.s)
+		if s == nil {
+			g.gameOver(cause)
+			return true
+		}
+		g.rival = s
+	}
+	return false
+}
+
+// Lives returns the shared lives left in a co-op run.
+func (g *Game) Lives() int {
+	return g.lives
+}
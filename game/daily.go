@@ -0,0 +1,13 @@
+package game
+
+// DailySeed derives the seed of the daily challenge of date, formatted
+// like 2006-01-02. Every player of the challenge gets the same food.
+func DailySeed(date string) int64 {
+	var seed int64
+	for _, c := range date {
+		if c >= '0' && c <= '9' {
+			seed = seed*10 + int64(c-'0')
+		}
+	}
+	return seed
+}
@@ -0,0 +1,59 @@
+package game
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventTurn is a turn a snake accepted, from the input or the
+	// autopilot.
+	EventTurn EventKind = iota
+	// EventEat is food eaten by a snake.
+	EventEat
+	// EventBonus is the time bonus for clearing a harvest wave.
+	EventBonus
+	// EventPoison is poison eaten by player one.
+	EventPoison
+	// EventPowerup is a power-up picked up by player one.
+	EventPowerup
+	// EventMilestone is the score of player one passing a multiple of
+	// 10000 points.
+	EventMilestone
+	// EventLevelComplete is the completion of a campaign level. After
+	// the last level the run is over and won.
+	EventLevelComplete
+	// EventGameOver is the end of the run by a crash.
+	EventGameOver
+	// EventLifeLost is the crash of the snake of Player costing a
+	// shared life in a co-op run, at X and Y. The snake starts over on
+	// a free spot.
+	EventLifeLost
+	// EventShrink is the arena closing in by one ring, with the Points
+	// player one scored for surviving it.
+	EventShrink
+	// EventGauntlet is obstacles spawned for the points of player one
+	// with the Gauntlet rule.
+	EventGauntlet
+)
+
+// Event is something that happened during a tick, for the frontend to
+// play sounds or show effects.
+type Event struct {
+	Kind EventKind
+	// Player is the player the event is about, 0 or 1.
+	Player int
+	// X and Y are the cell of food, poison, bonus and lost life events.
+	X, Y int
+	// Points are the points scored or lost.
+	Points int64
+	// Food is the type of food or poison eaten.
+	Food *FoodType
+	// Combo is the multiplier of the points of food eaten by player
+	// one, 0 without the Combo rule.
+	Combo int
+	// Direction is the direction of a turn.
+	Direction int
+}
+
+func (g *Game) emit(e Event) {
+	g.events = append(g.events, e)
+}
@@ -0,0 +1,293 @@
+package game
+
+// FoodType is a kind of food.
+type FoodType struct {
+	Name string
+	// Points are scored when it is eaten
+	Points int64
+	// Grow is the number of segments grown on top of the usual growth
+	Grow int
+	// Shrink is the number of tail segments lost when it is eaten
+	Shrink int
+	// Life is the number of frames it stays on the board before it
+	// turns into another food, 0 keeps it until it is eaten
+	Life int
+	// Weight is the relative spawn probability
+	Weight int
+}
+
+// FoodTypes is the registry of all kinds of food.
+var FoodTypes = []*FoodType{
+	{Name: "normal", Points: 1000, Weight: 80},
+	{Name: "bonus", Points: 5000, Life: 5 * 60, Weight: 12},
+	{Name: "mega", Points: 1000, Grow: 5, Weight: 8},
+	Poison,
+}
+
+// Poison is the hazard shrinking the snake. It is never picked as
+// regular food.
+var Poison = &FoodType{Name: "poison", Points: -2000, Shrink: 4, Life: 12 * 60}
+
+// Food is an item on the board. The forbidden food has no kind.
+type Food struct {
+	X, Y int
+	Kind *FoodType
+	// Life is the number of frames left before it expires, 0 if it
+	// does not expire
+	Life int
+}
+
+const (
+	// poisonInterval is the number of frames between two poison spawns.
+	poisonInterval = 6 * 60
+	// maxPoisons is the number of poison tiles on the board at most.
+	maxPoisons = 3
+	// minLength is the length below which poison kills the snake.
+	minLength = 2
+)
+
+// denseRatio is the share of free cells below which food is placed by
+// picking among the free cells instead of random sampling, which
+// needs more and more attempts as the board fills up.
+const denseRatio = 0.25
+
+// respawnTries is the number of random cells respawn tries before it
+// picks among the free cells. The items not counted as taken, like the
+// poison, may leave fewer free cells than denseRatio promises.
+const respawnTries = 64
+
+// respawn places the food on a random cell not taken by the snake or
+// other food.
+func (g *Game) respawn(f *Food) {
+	r, b := g.rules, g.board
+	if zone := g.currentLevel().foodZone(); zone != nil {
+		if r.Assist || (r.ReachableFirstFood && g.frame == 0) {
+			for i, ok := range g.reachable() {
+				zone[i] = zone[i] && ok
+			}
+		}
+		g.respawnFree(f, zone)
+		return
+	}
+	if r.Assist || (r.ReachableFirstFood && g.frame == 0) {
+		g.respawnFree(f, g.reachable())
+		return
+	}
+	total := (b.cellsX + 1) * (b.cellsY + 1)
+	taken := g.s.Len() + len(b.obstacles.cells) + len(g.foods)
+	if g.rival != nil {
+		taken += g.rival.Len()
+	}
+	if float64(total-taken) < denseRatio*float64(total) {
+		g.respawnFree(f, nil)
+		return
+	}
+	for i := 0; i < respawnTries; i++ {
+		x := g.rng.Intn(b.cellsX + 1)
+		y := g.rng.Intn(b.cellsY + 1)
+		if g.s.Occupies(x, y) || g.rival.Occupies(x, y) || b.obstacles.at(x, y) || g.foodAt(x, y) != -1 || g.forbiddenAt(x, y) || g.powerupAt(x, y) || g.poisonAt(x, y) != -1 {
+			continue
+		}
+		f.X = x
+		f.Y = y
+		return
+	}
+	g.respawnFree(f, nil)
+}
+
+// respawnFree places the food uniformly among the free cells. If reach
+// is not nil, only cells reachable by the head are considered, see
+// reachable. If there is no free cell left the food is taken off the
+// board.
+func (g *Game) respawnFree(f *Food, reach []bool) {
+	cellsX, cellsY := g.board.cellsX, g.board.cellsY
+	stride := cellsX + 1
+	taken := make([]bool, stride*(cellsY+1))
+	if reach != nil {
+		for i := range taken {
+			taken[i] = !reach[i]
+		}
+	}
+	mark := func(x, y int) {
+		if x >= 0 && x <= cellsX && y >= 0 && y <= cellsY {
+			taken[y*stride+x] = true
+		}
+	}
+	g.s.Each(func(c Cell) {
+		mark(c.X, c.Y)
+	})
+	if g.rival != nil {
+		g.rival.Each(func(c Cell) {
+			mark(c.X, c.Y)
+		})
+	}
+	for _, c := range g.board.obstacles.cells {
+		mark(c.X, c.Y)
+	}
+	for _, other := range g.foods {
+		if other != f {
+			mark(other.X, other.Y)
+		}
+	}
+	if g.forbidden != nil && g.forbidden != f {
+		mark(g.forbidden.X, g.forbidden.Y)
+	}
+	if g.powerup != nil && &g.powerup.Food != f {
+		mark(g.powerup.X, g.powerup.Y)
+	}
+	for _, p := range g.poisons {
+		if p != f {
+			mark(p.X, p.Y)
+		}
+	}
+	free := make([]int, 0, len(taken))
+	for i, t := range taken {
+		if !t {
+			free = append(free, i)
+		}
+	}
+	if len(free) == 0 {
+		f.X, f.Y = -1, -1
+		return
+	}
+	c := free[g.rng.Intn(len(free))]
+	f.X, f.Y = c%stride, c/stride
+}
+
+// foodAt returns the index of the food item at the given cell or -1.
+func (g *Game) foodAt(x, y int) int {
+	for i, f := range g.foods {
+		if f.X == x && f.Y == y {
+			return i
+		}
+	}
+	return -1
+}
+
+// forbiddenInterval is the number of frames after which the forbidden
+// food moves to another cell.
+const forbiddenInterval = 10 * 60
+
+// forbiddenAt reports whether the forbidden food is at the given cell.
+func (g *Game) forbiddenAt(x, y int) bool {
+	return g.forbidden != nil && g.forbidden.X == x && g.forbidden.Y == y
+}
+
+// harvestTime is the number of frames a wave can be cleared in for a
+// time bonus.
+const harvestTime = 30 * 60
+
+// spawnWave places n food items on the board.
+func (g *Game) spawnWave(n int) {
+	g.foods = g.foods[:0]
+	for i := 0; i < n; i++ {
+		f := &Food{X: -1, Y: -1}
+		g.renewFood(f)
+		g.foods = append(g.foods, f)
+	}
+	g.waveStart = g.frame
+}
+
+// waveCleared reports whether no food of the wave is left on the board.
+// Food taken off the board for lack of room counts as eaten.
+func (g *Game) waveCleared() bool {
+	for _, f := range g.foods {
+		if f.X >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// waveBonus returns the bonus for clearing a wave, 100 points for each
+// second left of harvestTime.
+func (g *Game) waveBonus() int64 {
+	left := harvestTime - (g.frame - g.waveStart)
+	if left < 0 {
+		return 0
+	}
+	return left / 60 * 100
+}
+
+// randomFoodType picks a kind of food by the spawn weights.
+func (g *Game) randomFoodType() *FoodType {
+	total := 0
+	for _, t := range FoodTypes {
+		total += t.Weight
+	}
+	n := g.rng.Intn(total)
+	for _, t := range FoodTypes {
+		if n < t.Weight {
+			return t
+		}
+		n -= t.Weight
+	}
+	return FoodTypes[0]
+}
+
+// renewFood turns f into a new random food on another cell.
+func (g *Game) renewFood(f *Food) {
+	f.Kind = g.randomFoodType()
+	f.Life = f.Kind.Life
+	g.respawn(f)
+}
+
+// poisonAt returns the index of the poison at the cell or -1.
+func (g *Game) poisonAt(x, y int) int {
+	for i, p := range g.poisons {
+		if p.X == x && p.Y == y {
+			return i
+		}
+	}
+	return -1
+}
+
+// updatePoison spawns and expires poison and lets the snake eat it. It
+// reports whether the poison killed the snake.
+func (g *Game) updatePoison() bool {
+	if g.frame%poisonInterval == 0 && len(g.poisons) < maxPoisons {
+		p := &Food{X: -1, Y: -1, Kind: Poison, Life: Poison.Life}
+		g.respawn(p)
+		g.poisons = append(g.poisons, p)
+	}
+	for i := 0; i < len(g.poisons); i++ {
+		p := g.poisons[i]
+		p.Life--
+		if p.Life <= 0 {
+			g.poisons = append(g.poisons[:i], g.poisons[i+1:]...)
+			i--
+		}
+	}
+	h := g.s.Head()
+	i := g.poisonAt(h.X, h.Y)
+	if i == -1 {
+		return false
+	}
+	g.poisons = append(g.poisons[:i], g.poisons[i+1:]...)
+	if g.s.Len()-Poison.Shrink < minLength {
+		return true
+	}
+	for n := 0; n < Poison.Shrink; n++ {
+		g.s.pop()
+	}
+	g.points += Poison.Points
+	if g.points < 0 {
+		g.points = 0
+	}
+	g.emit(Event{Kind: EventPoison, X: h.X, Y: h.Y, Points: Poison.Points, Food: Poison})
+	return false
+}
+
+// expireFoods counts down the food with a limited life and renews the
+// food which expired.
+func (g *Game) expireFoods() {
+	for _, f := range g.foods {
+		if f.Life == 0 {
+			continue
+		}
+		f.Life--
+		if f.Life == 0 {
+			g.renewFood(f)
+		}
+	}
+}
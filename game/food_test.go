@@ -0,0 +1,110 @@
+package game
+
+import "testing"
+
+// respawnCounts respawns a food n times and counts the cells it lands
+// on.
+func respawnCounts(g *Game, n int) map[Cell]int {
+	counts := make(map[Cell]int)
+	f := &Food{Kind: FoodTypes[0]}
+	for i := 0; i < n; i++ {
+		g.respawn(f)
+		counts[Cell{f.X, f.Y}]++
+	}
+	return counts
+}
+
+func TestRespawnUniform(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []Cell
+		// critical is the chi-square value for len(free)-1 degrees of
+		// freedom at p = 0.001
+		critical float64
+	}{
+		{
+			name:     "sampling",
+			cells:    []Cell{{0, 0}, {1, 0}, {2, 0}},
+			critical: 46.80,
+		},
+		{
+			name: "free cells",
+			cells: []Cell{
+				{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0},
+				{4, 1}, {3, 1}, {2, 1}, {1, 1}, {0, 1},
+				{0, 2}, {1, 2}, {2, 2}, {3, 2}, {4, 2},
+				{4, 3}, {3, 3}, {2, 3}, {1, 3}, {0, 3},
+			},
+			critical: 18.47,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(Rules{CellsX: 4, CellsY: 4, InitialLength: 3, Speed: 1, SpeedUp: 10000}, 1)
+			g.s = snakeOn(g.board, 0, tt.cells...)
+			g.foods = nil
+			var free []Cell
+			for y := 0; y <= 4; y++ {
+				for x := 0; x <= 4; x++ {
+					if !g.s.Occupies(x, y) {
+						free = append(free, Cell{x, y})
+					}
+				}
+			}
+			n := 1000 * len(free)
+			counts := respawnCounts(g, n)
+			expected := float64(n) / float64(len(free))
+			chi := 0.0
+			for _, c := range free {
+				d := float64(counts[c]) - expected
+				chi += d * d / expected
+				delete(counts, c)
+			}
+			if len(counts) > 0 {
+				t.Fatalf("food spawned on taken cells %v", counts)
+			}
+			if chi > tt.critical {
+				t.Errorf("chi-square %.2f over %.2f, the food is not spread evenly over %d cells", chi, tt.critical, len(free))
+			}
+		})
+	}
+}
+
+// TestRespawnCrowded fills the free cells with poison, which respawn
+// does not count as taken, so no random cell is ever free.
+func TestRespawnCrowded(t *testing.T) {
+	g := New(Rules{CellsX: 1, CellsY: 1, InitialLength: 1, Speed: 1, SpeedUp: 10000}, 1)
+	g.s = snakeOn(g.board, 0, Cell{0, 0})
+	g.foods = nil
+	g.poisons = []*Food{{X: 1, Y: 0, Kind: Poison}, {X: 0, Y: 1, Kind: Poison}, {X: 1, Y: 1, Kind: Poison}}
+	f := &Food{Kind: FoodTypes[0]}
+	g.respawn(f)
+	if f.X != -1 || f.Y != -1 {
+		t.Errorf("food on (%d, %d), want it off the board", f.X, f.Y)
+	}
+}
+
+// TestHarvestParked eats the last food of a wave on the board while
+// another one was taken off the board for lack of room.
+func TestHarvestParked(t *testing.T) {
+	normal := FoodTypes[0]
+	g := tickGame(Rules{Harvest: 2}, 0, []Cell{{2, 5}, {3, 5}, {4, 5}}, []Food{{X: 5, Y: 5, Kind: normal}, {X: -1, Y: -1, Kind: normal}})
+	g.Tick()
+	if len(g.foods) != 2 {
+		t.Fatalf("%d food on the board, want a new wave of 2", len(g.foods))
+	}
+	for _, f := range g.foods {
+		if f.X < 0 {
+			t.Errorf("food of the new wave off the board")
+		}
+	}
+}
+
+func BenchmarkRespawn(b *testing.B) {
+	g := New(Rules{CellsX: 60, CellsY: 40, InitialLength: 3, Speed: 12, SpeedUp: 10000}, 1)
+	f := &Food{Kind: FoodTypes[0]}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.respawn(f)
+	}
+}
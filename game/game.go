@@ -0,0 +1,710 @@
+// Package game implements the rules of snake: the board, the snakes,
+// food, power-ups and scoring, advanced one frame at a time by Tick. It
+// draws nothing and reads no input, so the same rules run behind the
+// ebiten frontend, replays and bots.
+package game
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/wongak/snake/bot"
+)
+
+// MinSpeed is the smallest number of frames between two steps.
+const MinSpeed = 1
+
+// maxCrowding is the share of the board obstacles and the food of a
+// harvest wave take at most each, as a divisor of the number of cells.
+const maxCrowding = 4
+
+// Rules configures a run. See snake.Options for the meaning of the
+// fields sharing their names.
+type Rules struct {
+	// CellsX and CellsY are the board size, replaced by the size of
+	// Level or the campaign levels.
+	CellsX, CellsY int
+
+	InitialLength int
+	Speed         float64
+	SpeedUp       float64
+	Chaos         bool
+	Harvest       int
+	Walls         bool
+	// Level is the arena layout, nil for an empty board.
+	Level *Level
+	// Campaign are the levels played in order, see CampaignLevels. It
+	// replaces Level if not empty.
+	Campaign  []*Level
+	TwoPlayer bool
+	// LongerWins lets the longer snake of a two-player game survive
+	// the heads of both snakes meeting, see headOn. Without it, or if
+	// both are as long, both crash and the higher score wins.
+	LongerWins bool
+	// Coop adds a second snake playing together with player one: its
+	// food counts towards the score of player one and every crash costs
+	// one of Lives shared lives, DefaultLives if 0. It is ignored in
+	// two-player games.
+	Coop  bool
+	Lives int
+	// Shrink closes the arena in by one ring of walls every
+	// ShrinkInterval frames until it reached its smallest size. Snakes
+	// caught by the walls crash, the ones surviving score a bonus.
+	// There is no campaign with Shrink.
+	Shrink bool
+	// Gauntlet spawns obstacles every time player one scored this many
+	// points more, away from the snakes and the food. 0 spawns none.
+	Gauntlet int
+	AI       bool
+	// Bot steers the computer snake, bot.Greedy if nil.
+	Bot bot.Bot `json:"-"`
+	// Autopilot steers the snake of player one if set.
+	Autopilot          bot.Bot `json:"-"`
+	Tron               bool
+	Powerups           bool
+	Poison             bool
+	Obstacles          int
+	WrapGrace          bool
+	GrowPerFood        int
+	Forbidden          bool
+	ReachableFirstFood bool
+	Assist             bool
+	SlowMotion         bool
+	// Combo multiplies the points of food eaten in quick succession,
+	// see Game.Combo.
+	Combo bool
+}
+
+const (
+	// comboWindow is the number of frames after eating in which the
+	// next food raises the combo.
+	comboWindow = 3 * 60
+	// maxCombo is the largest combo multiplier.
+	maxCombo = 5
+)
+
+// Turn turns the snake of a player on one of the next steps.
+type Turn struct {
+	// Player is 0 for player one and 1 for player two.
+	Player int
+	// Direction is 0 right, 1 down, 2 left or 3 up.
+	Direction int
+}
+
+// Game is a run of snake.
+type Game struct {
+	rules Rules
+	rng   *rand.Rand
+	board *board
+
+	s     *Snake
+	foods []*Food
+	// poisons are the poison tiles on the board
+	poisons []*Food
+	// forbidden is the food ending the game, nil if disabled
+	forbidden *Food
+	frame     int64
+	// lastStep and nextStep are the frames of the last and the next
+	// step
+	lastStep  int64
+	nextStep  int64
+	waveStart int64
+	points    int64
+
+	// level is the index of the current campaign level and levelEaten
+	// the food eaten on it. complete is set from the completion of a
+	// level until NextLevel, won once the last level is completed.
+	level      int
+	levelEaten int
+	complete   bool
+	won        bool
+	// over is set once the run ended, cause is what player one crashed
+	// into
+	over  bool
+	cause Cause
+
+	// rival is the snake of player two or the computer snake, nil in
+	// single player games. winner is the winning player of a two-player
+	// game, 0 on a draw. aiRespawn counts the frames until a crashed
+	// computer snake comes back.
+	rival       *Snake
+	rivalPoints int64
+	winner      int
+	aiRespawn   int
+	// lives are the shared lives left in a co-op run
+	lives int
+	// shrunk is the number of rings the arena shrank by
+	shrunk int
+	// gauntletSpawns is the number of times obstacles were spawned for
+	// the points scored, see Rules.Gauntlet
+	gauntletSpawns int
+
+	// powerup is the pickup on the board, nil if there is none. effect
+	// is the running timed effect for effectLeft more frames.
+	powerup    *Powerup
+	effect     PowerupKind
+	effectLeft int
+
+	// reversed is the number of frames the controls stay reversed
+	reversed int
+
+	// run statistics
+	eaten     int
+	maxLength int
+
+	// combo is the multiplier of the last food eaten by player one, at
+	// lastEat
+	combo   int
+	lastEat int64
+
+	// events are the events of the current tick
+	events []Event
+}
+
+// New creates a game with the given rules and starts a run with seed.
+func New(r Rules, seed int64) *Game {
+	if r.Speed < MinSpeed {
+		r.Speed = MinSpeed
+	}
+	if r.TwoPlayer {
+		r.Coop = false
+	}
+	if r.TwoPlayer || r.Coop || r.Tron || r.Shrink {
+		r.Campaign = nil
+	}
+	if r.TwoPlayer || r.Coop {
+		r.AI = false
+	}
+	if r.Coop {
+		r.Tron = false
+		if r.Lives < 1 {
+			r.Lives = DefaultLives
+		}
+	}
+	if r.Bot == nil {
+		r.Bot = bot.Greedy{}
+	}
+	if len(r.Campaign) > 0 {
+		r.Level = r.Campaign[0]
+	}
+	if r.Level != nil {
+		r.CellsX, r.CellsY = r.Level.Size()
+	}
+	// the snake fits into a row of CellsX+1 cells but has at least its
+	// head
+	if r.InitialLength > r.CellsX+1 {
+		r.InitialLength = r.CellsX + 1
+	}
+	if r.InitialLength < 1 {
+		r.InitialLength = 1
+	}
+	most := (r.CellsX + 1) * (r.CellsY + 1) / maxCrowding
+	if r.Obstacles > most {
+		r.Obstacles = most
+	}
+	if r.Harvest > most {
+		r.Harvest = most
+	}
+	g := &Game{
+		rules: r,
+		rng:   rand.New(rand.NewSource(seed)),
+		board: &board{
+			cellsX:    r.CellsX,
+			cellsY:    r.CellsY,
+			walls:     r.Walls,
+			obstacles: newObstacles(r.CellsX, r.CellsY),
+		},
+	}
+	g.Reset(seed)
+	return g
+}
+
+// Reset starts a new run seeded with seed, resetting score and speed.
+func (g *Game) Reset(seed int64) {
+	g.rng.Seed(seed)
+	g.frame = 0
+	g.points = 0
+	g.eaten = 0
+	g.maxLength = 0
+	g.combo, g.lastEat = 0, 0
+	g.level = 0
+	g.won = false
+	g.over = false
+	g.cause = CauseNone
+	g.rivalPoints = 0
+	g.winner = 0
+	g.lives = g.rules.Lives
+	g.shrunk = 0
+	g.gauntletSpawns = 0
+	g.startLevel()
+}
+
+// NextLevel starts the next campaign level after a level was completed,
+// keeping the score.
+func (g *Game) NextLevel() {
+	if !g.complete {
+		return
+	}
+	g.level++
+	g.startLevel()
+}
+
+// currentLevel returns the arena of the current level, nil for an empty
+// board.
+func (g *Game) currentLevel() *Level {
+	if len(g.rules.Campaign) > 0 {
+		return g.rules.Campaign[g.level]
+	}
+	return g.rules.Level
+}
+
+// startLevel places the snake, the obstacles and the food for the
+// current level.
+func (g *Game) startLevel() {
+	r, b := g.rules, g.board
+	l := g.currentLevel()
+	g.complete = false
+	g.levelEaten = 0
+	g.powerup = nil
+	g.poisons = nil
+	g.effectLeft = 0
+	g.reversed = 0
+	g.foods = nil
+	g.forbidden = nil
+	start := Cell{b.cellsX / 2, b.cellsY / 2}
+	if r.TwoPlayer || r.Coop || r.AI {
+		start.Y = b.cellsY / 3
+	}
+	if l != nil && l.hasStart {
+		start = l.start
+	}
+	g.s = newSnake(b, r.InitialLength, start)
+	g.rival = nil
+	if r.TwoPlayer || r.Coop {
+		g.initRival()
+	}
+	g.lastStep, g.nextStep = g.frame, g.frame+g.stepInterval()
+	b.obstacles.clear()
+	if l != nil {
+		for _, c := range l.walls {
+			b.obstacles.add(c.X, c.Y)
+		}
+	}
+	g.spawnObstacles(r.Obstacles)
+	if r.AI {
+		g.spawnAI()
+	}
+	g.updateChaos(g.frame + 1)
+	if r.Tron {
+		return
+	}
+	if r.Harvest > 0 {
+		g.spawnWave(r.Harvest)
+	} else {
+		g.spawnWave(1)
+	}
+	if r.Forbidden {
+		g.forbidden = &Food{X: -1, Y: -1}
+		g.respawn(g.forbidden)
+	}
+}
+
+// stepInterval returns the number of frames between two steps. It
+// decreases with the points but never drops below MinSpeed.
+func (g *Game) stepInterval() int64 {
+	speed := g.rules.Speed - float64(g.level*levelSpeedUp)
+	if g.rules.SpeedUp > 0 {
+		speed -= float64(g.points+g.rivalPoints) / g.rules.SpeedUp
+	}
+	switch {
+	case g.effectActive(PowerupFast):
+		speed /= 2
+	case g.effectActive(PowerupSlow):
+		speed *= 2
+	}
+	currSpeed := int64(speed)
+	if currSpeed < MinSpeed {
+		return MinSpeed
+	}
+	return currSpeed
+}
+
+// StepInterval returns the number of ticks between two steps at the
+// current score, level and power-up.
+func (g *Game) StepInterval() int64 {
+	return g.stepInterval()
+}
+
+// slowMotionFactor is how much longer a step takes in slow motion.
+const slowMotionFactor = 3
+
+// danger reports whether the snake dies if it keeps its direction for
+// one more step.
+func (g *Game) danger() bool {
+	b := g.board
+	h := g.s.Head()
+	x, y := b.neighbor(h.X, h.Y, g.s.direction)
+	return b.crossesWall(h.X, h.Y, x, y) || b.obstacles.at(x, y) || g.s.Occupies(x, y) || g.rival.Occupies(x, y) || g.forbiddenAt(x, y)
+}
+
+const (
+	// chaosInterval is the number of frames between two control reversals
+	chaosInterval = 20 * 60
+	// chaosDuration is the number of frames the controls stay reversed
+	chaosDuration = 5 * 60
+)
+
+// updateChaos reverses the controls for the given frame. It runs one
+// frame ahead, so Reversed tells the frontend how to read the controls
+// for the next tick.
+func (g *Game) updateChaos(frame int64) {
+	if !g.rules.Chaos {
+		return
+	}
+	if frame%chaosInterval == 0 {
+		g.reversed = chaosDuration
+	}
+	if g.reversed > 0 {
+		g.reversed--
+	}
+}
+
+// framesPerTronPoint is the number of frames survived per point in
+// tron games, so the score is in tenths of a second.
+const framesPerTronPoint = 6
+
+// Tick advances the run by one frame, applying the turns first. Turns
+// from the controls are expected to be inverted already while Reversed
+// is set. Tick returns the events of the frame, which are only valid
+// until the next call. After the run ended or a level was completed
+// Tick does nothing.
+func (g *Game) Tick(turns ...Turn) []Event {
+	g.events = nil
+	if g.over || g.complete {
+		return nil
+	}
+	r, s := g.rules, g.s
+	g.frame++
+	if r.Tron {
+		g.points = g.frame / framesPerTronPoint
+		if g.rival != nil {
+			g.rivalPoints = g.points
+		}
+	}
+	for _, t := range turns {
+		g.turnSnake(t.Player, t.Direction)
+	}
+	if g.frame >= g.nextStep {
+		if r.Tron {
+			// the tail stays in place, leaving the trail behind
+			s.grow = 1
+			if g.rival != nil {
+				g.rival.grow = 1
+			}
+		}
+		if r.Autopilot != nil {
+			if d, ok := g.botMove(r.Autopilot, s, g.rival); ok {
+				g.turnSnake(0, d)
+			}
+		}
+		if r.AI && g.rival != nil {
+			if d, ok := g.botMove(r.Bot, g.rival, s); ok {
+				g.rival.turn(d)
+			}
+		}
+		s.move(g.board)
+		if g.rival != nil {
+			g.rival.move(g.board)
+		}
+		g.lastStep, g.nextStep = g.frame, g.frame+g.stepInterval()
+		if r.SlowMotion && g.danger() {
+			g.nextStep += g.stepInterval() * (slowMotionFactor - 1)
+		}
+		if r.Shrink {
+			g.shrink()
+		}
+		if r.TwoPlayer {
+			one, two := g.headOn(g.crashCause(s, g.rival), g.crashCause(g.rival, s))
+			if one != CauseNone || two != CauseNone {
+				g.twoPlayerOver(one, two)
+				return g.events
+			}
+			if !r.Tron {
+				g.rivalPoints += 10
+			}
+		} else if r.Coop {
+			if g.coopCrash() {
+				return g.events
+			}
+			// the snake may have started over
+			s = g.s
+		} else if c := g.crashCause(s, g.rival); c != CauseNone {
+			g.gameOver(c)
+			return g.events
+		} else if g.rival != nil && g.crashed(g.rival, s) {
+			g.killAI()
+		}
+		if !r.Tron {
+			g.addPoints(10)
+		}
+		if l := s.Len(); l > g.maxLength {
+			g.maxLength = l
+		}
+	}
+	h := s.Head()
+	if g.forbidden != nil {
+		if g.forbiddenAt(h.X, h.Y) {
+			g.gameOver(CauseForbidden)
+			return g.events
+		}
+		if g.frame%forbiddenInterval == 0 {
+			g.respawn(g.forbidden)
+		}
+	}
+	g.expireFoods()
+	if r.Poison && g.updatePoison() {
+		g.gameOver(CausePoison)
+		return g.events
+	}
+	// eat
+	if i := g.foodAt(h.X, h.Y); i != -1 {
+		f := g.foods[i]
+		g.eaten++
+		points := f.Kind.Points
+		if r.Combo {
+			g.raiseCombo()
+			points *= int64(g.combo)
+		}
+		g.addPoints(points)
+		g.emit(Event{Kind: EventEat, X: f.X, Y: f.Y, Points: points, Food: f.Kind, Combo: g.combo})
+		if r.GrowPerFood > 0 {
+			s.grow += r.GrowPerFood
+		} else {
+			s.grow = int(math.Log10(float64(g.points)))
+		}
+		s.grow += f.Kind.Grow
+		if r.Harvest > 0 {
+			g.foods = append(g.foods[:i], g.foods[i+1:]...)
+			if g.waveCleared() {
+				bonus := g.waveBonus()
+				g.addPoints(bonus)
+				if bonus > 0 {
+					g.emit(Event{Kind: EventBonus, X: f.X, Y: f.Y + 1, Points: bonus})
+				}
+				g.spawnWave(r.Harvest)
+			}
+		} else {
+			g.renewFood(f)
+		}
+		g.levelEaten++
+		if len(r.Campaign) > 0 && g.levelEaten >= LevelFood {
+			g.completeLevel()
+			return g.events
+		}
+	}
+
+	if r.Gauntlet > 0 {
+		g.gauntlet()
+	}
+	if r.AI {
+		g.updateAI()
+	}
+	if g.rival != nil {
+		g.rivalEat()
+	}
+	if r.Powerups {
+		g.updatePowerups()
+	}
+	g.updateChaos(g.frame + 1)
+	return g.events
+}
+
+// turnSnake turns the snake of player 0 or 1.
+func (g *Game) turnSnake(player, direction int) {
+	s := g.s
+	if player == 1 {
+		s = g.rival
+	}
+	if s == nil || !s.turn(direction) {
+		return
+	}
+	g.emit(Event{Kind: EventTurn, Player: player, Direction: direction})
+}
+
+// gameOver ends the run by the crash of player one for cause.
+func (g *Game) gameOver(cause Cause) {
+	g.over = true
+	g.cause = cause
+	g.emit(Event{Kind: EventGameOver})
+}
+
+// milestone is the number of points between two milestones.
+const milestone = 10000
+
+// addPoints adds n points to the score of player one.
+func (g *Game) addPoints(n int64) {
+	if (g.points+n)/milestone > g.points/milestone {
+		g.emit(Event{Kind: EventMilestone})
+	}
+	g.points += n
+}
+
+// Size returns the board size. The board reaches from 0 to cellsX and
+// cellsY inclusive.
+func (g *Game) Size() (cellsX, cellsY int) {
+	return g.board.cellsX, g.board.cellsY
+}
+
+// Snake returns the snake of player one.
+func (g *Game) Snake() *Snake {
+	return g.s
+}
+
+// Rival returns the snake of player two or the computer snake, nil if
+// there is none.
+func (g *Game) Rival() *Snake {
+	return g.rival
+}
+
+// Foods returns the food on the board. Food without a free cell left
+// has a negative position.
+func (g *Game) Foods() []*Food {
+	return g.foods
+}
+
+// Forbidden returns the forbidden food, nil if there is none.
+func (g *Game) Forbidden() *Food {
+	return g.forbidden
+}
+
+// Poisons returns the poison on the board.
+func (g *Game) Poisons() []*Food {
+	return g.poisons
+}
+
+// Powerup returns the power-up on the board, nil if there is none.
+func (g *Game) Powerup() *Powerup {
+	return g.powerup
+}
+
+// Obstacles returns the blocked cells, including the walls of the level.
+func (g *Game) Obstacles() []Cell {
+	return g.board.obstacles.cells
+}
+
+// Points returns the score of player one.
+func (g *Game) Points() int64 {
+	return g.points
+}
+
+// RivalPoints returns the score of player two or the computer snake.
+func (g *Game) RivalPoints() int64 {
+	return g.rivalPoints
+}
+
+// Frame returns the number of frames played.
+func (g *Game) Frame() int64 {
+	return g.frame
+}
+
+// StepProgress returns how far the run is between the last and the next
+// step, from 0 right after a step to 1 on the next one. sub is the
+// fraction of the next frame passed, for frontends drawing in between
+// frames. Finished runs and levels return 1.
+func (g *Game) StepProgress(sub float64) float64 {
+	if g.over || g.complete || g.nextStep <= g.lastStep {
+		return 1
+	}
+	p := (float64(g.frame-g.lastStep) + sub) / float64(g.nextStep-g.lastStep)
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
+// Level returns the index of the current campaign level.
+func (g *Game) Level() int {
+	return g.level
+}
+
+// LevelEaten returns the food eaten on the current campaign level.
+func (g *Game) LevelEaten() int {
+	return g.levelEaten
+}
+
+// LevelComplete reports whether a campaign level was completed and the
+// run waits for NextLevel.
+func (g *Game) LevelComplete() bool {
+	return g.complete
+}
+
+// Won reports whether the last campaign level was completed.
+func (g *Game) Won() bool {
+	return g.won
+}
+
+// Over reports whether the run ended.
+func (g *Game) Over() bool {
+	return g.over
+}
+
+// Cause returns what ended the run of player one, CauseNone while it
+// is running, after winning the campaign or when only the other snake
+// crashed.
+func (g *Game) Cause() Cause {
+	return g.cause
+}
+
+// Winner returns the winning player of a two-player game, 0 on a draw.
+func (g *Game) Winner() int {
+	return g.winner
+}
+
+// Reversed reports whether the controls are reversed on the next tick.
+func (g *Game) Reversed() bool {
+	return g.reversed > 0
+}
+
+// Effect returns the running timed effect and the number of frames it
+// lasts. It lasts 0 frames if there is none.
+func (g *Game) Effect() (PowerupKind, int) {
+	return g.effect, g.effectLeft
+}
+
+// raiseCombo raises the combo if the last food was eaten within the
+// combo window, otherwise it starts over.
+func (g *Game) raiseCombo() {
+	if g.combo > 0 && g.frame-g.lastEat <= comboWindow {
+		if g.combo < maxCombo {
+			g.combo++
+		}
+	} else {
+		g.combo = 1
+	}
+	g.lastEat = g.frame
+}
+
+// Combo returns the multiplier of the points of the next food if it is
+// eaten within three seconds of the last one, and the frames left to
+// eat it. Every food eaten in time raises the multiplier by one, up to
+// five. Without the Combo rule it is always 1.
+func (g *Game) Combo() (multiplier int, left int64) {
+	left = g.lastEat + comboWindow - g.frame
+	if !g.rules.Combo || g.combo == 0 || left < 0 {
+		return 1, 0
+	}
+	if g.combo < maxCombo {
+		return g.combo + 1, left
+	}
+	return g.combo, left
+}
+
+// Eaten returns the number of food items player one ate.
+func (g *Game) Eaten() int {
+	return g.eaten
+}
+
+// MaxLength returns the largest length the snake of player one had.
+func (g *Game) MaxLength() int {
+	return g.maxLength
+}
@@ -0,0 +1,292 @@
+package game
+
+import "testing"
+
+// FuzzTick plays runs on boards of any size with turns of any order and
+// checks the snake after every tick: it stays on the board, takes no
+// cell twice and grows by one cell on every step it is growing.
+func FuzzTick(f *testing.F) {
+	f.Add(int64(1), uint8(20), uint8(10), uint8(3), false, []byte{0, 1, 2, 3})
+	f.Add(int64(2), uint8(2), uint8(2), uint8(2), true, []byte{1, 1, 2, 2, 3, 3, 0, 0})
+	f.Add(int64(3), uint8(0), uint8(0), uint8(0), false, []byte{})
+	f.Fuzz(func(t *testing.T, seed int64, cellsX, cellsY, length uint8, walls bool, turns []byte) {
+		r := Rules{
+			CellsX:        int(cellsX%40) + 1,
+			CellsY:        int(cellsY%40) + 1,
+			InitialLength: int(length % 8),
+			Speed:         1,
+			SpeedUp:       10000,
+			Walls:         walls,
+		}
+		g := New(r, seed)
+		w, h := g.Size()
+		for i := 0; i < 4*len(turns)+100 && !g.Over(); i++ {
+			s := g.Snake()
+			head, n, grow := s.Head(), s.Len(), s.Growing()
+			var tt []Turn
+			if i < len(turns) && turns[i]&4 != 0 {
+				tt = append(tt, Turn{Direction: int(turns[i] % 4)})
+			}
+			g.Tick(tt...)
+			if g.Over() {
+				break
+			}
+			s = g.Snake()
+			if c := s.Head(); c.X < 0 || c.Y < 0 || c.X > w || c.Y > h {
+				t.Fatalf("tick %d: head %v off the board of %dx%d", i, c, w, h)
+			}
+			want := n
+			if s.Head() != head && grow > 0 {
+				want++
+			}
+			if s.Len() != want {
+				t.Fatalf("tick %d: length %d, want %d", i, s.Len(), want)
+			}
+			seen := make(map[Cell]bool)
+			s.Each(func(c Cell) {
+				if seen[c] {
+					t.Fatalf("tick %d: two segments on %v", i, c)
+				}
+				seen[c] = true
+			})
+		}
+	})
+}
+
+// tickGame returns a run with the snake on cells, from the tail to the
+// head, moving in direction and the given foods as the only foods. The
+// board is 10x10 cells unless the rules set its size.
+func tickGame(r Rules, direction int, cells []Cell, foods []Food) *Game {
+	if r.CellsX == 0 {
+		r.CellsX, r.CellsY = 9, 9
+	}
+	r.InitialLength = len(cells)
+	r.Speed, r.SpeedUp = 1, 10000
+	g := New(r, 1)
+	g.s = snakeOn(g.board, direction, cells...)
+	g.foods = nil
+	for i := range foods {
+		g.foods = append(g.foods, &foods[i])
+	}
+	return g
+}
+
+func TestTick(t *testing.T) {
+	normal := FoodTypes[0]
+	tests := []struct {
+		name      string
+		rules     Rules
+		direction int
+		cells     []Cell
+		foods     []Food
+		// turns are the turns given on each tick
+		turns [][]int
+		// heads are the head cells after each tick
+		heads []Cell
+		len   int
+		cause Cause
+	}{
+		{
+			name:      "eat and grow",
+			rules:     Rules{GrowPerFood: 1},
+			direction: 0,
+			cells:     []Cell{{2, 5}, {3, 5}, {4, 5}},
+			foods:     []Food{{X: 5, Y: 5, Kind: normal}},
+			turns:     make([][]int, 3),
+			heads:     []Cell{{5, 5}, {6, 5}, {7, 5}},
+			len:       4,
+		},
+		{
+			name:      "wall crash",
+			rules:     Rules{Walls: true},
+			direction: 0,
+			cells:     []Cell{{7, 5}, {8, 5}, {9, 5}},
+			turns:     make([][]int, 1),
+			heads:     []Cell{{0, 5}},
+			len:       3,
+			cause:     CauseWall,
+		},
+		{
+			name:      "self crash",
+			direction: 2,
+			cells:     []Cell{{3, 5}, {4, 5}, {5, 5}, {5, 4}, {4, 4}},
+			turns:     [][]int{{1}},
+			heads:     []Cell{{4, 5}},
+			len:       5,
+			cause:     CauseSelf,
+		},
+		{
+			name:      "wrap around the edge",
+			direction: 0,
+			cells:     []Cell{{7, 5}, {8, 5}, {9, 5}},
+			turns:     [][]int{nil, {3}, nil},
+			heads:     []Cell{{0, 5}, {0, 4}, {0, 3}},
+			len:       3,
+		},
+		{
+			name:      "wrap at the top",
+			direction: 3,
+			cells:     []Cell{{4, 2}, {4, 1}, {4, 0}},
+			turns:     make([][]int, 2),
+			heads:     []Cell{{4, 9}, {4, 8}},
+			len:       3,
+		},
+		{
+			name:      "wrap onto the body",
+			direction: 0,
+			cells:     []Cell{{0, 4}, {0, 5}, {0, 6}, {9, 6}, {9, 5}},
+			turns:     make([][]int, 1),
+			heads:     []Cell{{0, 5}},
+			len:       5,
+			cause:     CauseSelf,
+		},
+		{
+			name:      "wrap grace",
+			rules:     Rules{WrapGrace: true},
+			direction: 0,
+			cells:     []Cell{{0, 4}, {0, 5}, {0, 6}, {9, 6}, {9, 5}},
+			turns:     make([][]int, 2),
+			heads:     []Cell{{0, 5}, {1, 5}},
+			len:       5,
+		},
+		{
+			name:      "wrap grace only on the wrapping step",
+			rules:     Rules{WrapGrace: true},
+			direction: 0,
+			cells:     []Cell{{0, 4}, {0, 5}, {0, 6}, {9, 6}, {9, 5}},
+			turns:     [][]int{nil, {1}},
+			heads:     []Cell{{0, 5}, {0, 6}},
+			len:       5,
+			cause:     CauseSelf,
+		},
+		{
+			name:      "two turns buffered",
+			direction: 0,
+			cells:     []Cell{{2, 5}, {3, 5}, {4, 5}},
+			turns:     [][]int{{1, 2}, nil, nil},
+			heads:     []Cell{{4, 6}, {3, 6}, {2, 6}},
+			len:       3,
+		},
+		{
+			name:      "third turn dropped",
+			direction: 0,
+			cells:     []Cell{{2, 5}, {3, 5}, {4, 5}},
+			turns:     [][]int{{1, 2, 3}, nil, nil},
+			heads:     []Cell{{4, 6}, {3, 6}, {2, 6}},
+			len:       3,
+		},
+		{
+			name:      "reversal ignored",
+			direction: 0,
+			cells:     []Cell{{2, 5}, {3, 5}, {4, 5}},
+			turns:     [][]int{{2}, nil},
+			heads:     []Cell{{5, 5}, {6, 5}},
+			len:       3,
+		},
+		{
+			name:      "double tap turns once",
+			direction: 0,
+			cells:     []Cell{{2, 5}, {3, 5}, {4, 5}},
+			turns:     [][]int{{1, 0}, nil},
+			heads:     []Cell{{4, 6}, {4, 7}},
+			len:       3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tickGame(tt.rules, tt.direction, tt.cells, tt.foods)
+			for i, directions := range tt.turns {
+				var turns []Turn
+				for _, d := range directions {
+					turns = append(turns, Turn{Direction: d})
+				}
+				for _, e := range g.Tick(turns...) {
+					if e.Kind == EventEat {
+						// keep the renewed food out of the way
+						g.foods = nil
+					}
+				}
+				if h := g.Snake().Head(); h != tt.heads[i] {
+					t.Fatalf("tick %d: head on %v, want %v", i, h, tt.heads[i])
+				}
+				if g.Over() && i < len(tt.turns)-1 {
+					t.Fatalf("tick %d: the run ended early by %v", i, g.Cause())
+				}
+			}
+			if n := g.Snake().Len(); n != tt.len {
+				t.Errorf("length %d, want %d", n, tt.len)
+			}
+			if g.Over() != (tt.cause != CauseNone) || g.Cause() != tt.cause {
+				t.Errorf("the run ended by %v, want %v", g.Cause(), tt.cause)
+			}
+		})
+	}
+}
+
+func TestStepInterval(t *testing.T) {
+	tests := []struct {
+		points int64
+		want   int64
+	}{
+		{0, 5},
+		{1, 4},
+		{1000, 4},
+		{1001, 3},
+		{2000, 3},
+		{2001, 2},
+		{3000, 2},
+		{3001, 1},
+		{4000, 1},
+		{4001, MinSpeed},
+		{5000, MinSpeed},
+		{1000000, MinSpeed},
+	}
+	g := New(Rules{CellsX: 20, CellsY: 10, InitialLength: 3, Speed: 5, SpeedUp: 1000}, 1)
+	for _, tt := range tests {
+		g.points = tt.points
+		if got := g.stepInterval(); got != tt.want {
+			t.Errorf("%d points: step interval %d, want %d", tt.points, got, tt.want)
+		}
+	}
+}
+
+func TestGrowth(t *testing.T) {
+	for _, kind := range FoodTypes[:3] {
+		for _, grow := range []int{1, 2, 3} {
+			r := Rules{CellsX: 39, CellsY: 9, GrowPerFood: grow}
+			g := tickGame(r, 0, []Cell{{2, 5}, {3, 5}, {4, 5}}, []Food{{X: 5, Y: 5, Kind: kind}})
+			for i := 0; i < 20; i++ {
+				g.Tick()
+				g.foods = nil
+			}
+			if want := 3 + grow + kind.Grow; g.Snake().Len() != want {
+				t.Errorf("%s food, %d per food: length %d, want %d", kind.Name, grow, g.Snake().Len(), want)
+			}
+		}
+	}
+}
+
+func TestNewInitialLength(t *testing.T) {
+	tests := []struct {
+		length, want int
+	}{
+		{-3, 1},
+		{0, 1},
+		{1, 1},
+		{5, 5},
+		{20, 20},
+		{21, 21},
+		{22, 21},
+		{100, 21},
+	}
+	for _, tt := range tests {
+		g := New(Rules{CellsX: 20, CellsY: 10, InitialLength: tt.length, Speed: 1, SpeedUp: 10000}, 1)
+		if n := g.Snake().Len(); n != tt.want {
+			t.Errorf("initial length %d: snake of %d cells, want %d", tt.length, n, tt.want)
+		}
+	}
+	g := New(Rules{CellsX: 0, CellsY: 10, InitialLength: 3, Speed: 1, SpeedUp: 10000}, 1)
+	if n := g.Snake().Len(); n != 1 {
+		t.Errorf("single column: snake of %d cells, want 1", n)
+	}
+}
@@ -0,0 +1,48 @@
+package game
+
+const (
+	// DefaultGauntlet is the number of points between two spawns of
+	// obstacles in the gauntlet mode unless set otherwise.
+	DefaultGauntlet = 500
+	// gauntletBlocks is the number of obstacles spawned on each
+	// milestone of the gauntlet mode.
+	gauntletBlocks = 3
+	// gauntletClearance is the number of cells around the heads of the
+	// snakes kept free of the obstacles spawned.
+	gauntletClearance = 3
+)
+
+// gauntlet spawns gauntletBlocks obstacles for every Rules.Gauntlet
+// points player one scored since the last spawn.
+func (g *Game) gauntlet() {
+	for reached := g.points / int64(g.rules.Gauntlet); int64(g.gauntletSpawns) < reached; g.gauntletSpawns++ {
+		for i := 0; i < gauntletBlocks; i++ {
+			g.placeObstacle()
+		}
+		g.emit(Event{Kind: EventGauntlet})
+	}
+}
+
+// placeObstacle adds an obstacle on a random free cell away from the
+// heads of the snakes. It gives up on crowded boards.
+func (g *Game) placeObstacle() {
+	b := g.board
+	near := func(s *Snake, x, y int) bool {
+		if s == nil {
+			return false
+		}
+		h := s.Head()
+		return abs(x-h.X)+abs(y-h.Y) <= gauntletClearance
+	}
+	for try := 0; try < 100; try++ {
+		x, y := g.rng.Intn(b.cellsX+1), g.rng.Intn(b.cellsY+1)
+		if near(g.s, x, y) || near(g.rival, x, y) || b.obstacles.at(x, y) || g.s.Occupies(x, y) || g.rival.Occupies(x, y) {
+			continue
+		}
+		if g.foodAt(x, y) != -1 || g.forbiddenAt(x, y) || g.powerupAt(x, y) || g.poisonAt(x, y) != -1 {
+			continue
+		}
+		b.obstacles.add(x, y)
+		return
+	}
+}
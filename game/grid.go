@@ -0,0 +1,79 @@
+package game
+
+// board is the playing field. It reaches from 0 to cellsX and cellsY
+// inclusive.
+type board struct {
+	cellsX, cellsY int
+	// walls makes the board edges solid
+	walls     bool
+	obstacles *obstacles
+}
+
+// neighbor returns the cell next to (x, y) in the given direction,
+// wrapping around the board edges like the snake does.
+func (b *board) neighbor(x, y, direction int) (int, int) {
+	switch direction % 4 {
+	case 0:
+		x++
+	case 1:
+		y++
+	case 2:
+		x--
+	case 3:
+		y--
+	}
+	switch {
+	case x < 0:
+		x = b.cellsX
+	case x > b.cellsX:
+		x = 0
+	case y < 0:
+		y = b.cellsY
+	case y > b.cellsY:
+		y = 0
+	}
+	return x, y
+}
+
+// crossesWall reports whether the step from (x, y) to its neighbor
+// (nx, ny) wraps around a solid board edge.
+func (b *board) crossesWall(x, y, nx, ny int) bool {
+	return b.walls && abs(nx-x)+abs(ny-y) != 1
+}
+
+// reachable flood-fills the board from the head and returns for every
+// cell, indexed by y*(cellsX+1)+x, whether the head can get there
+// without crossing the body.
+func (g *Game) reachable() []bool {
+	b := g.board
+	stride := b.cellsX + 1
+	seen := make([]bool, stride*(b.cellsY+1))
+	g.s.Each(func(c Cell) {
+		seen[c.Y*stride+c.X] = true
+	})
+	if g.rival != nil {
+		g.rival.Each(func(c Cell) {
+			seen[c.Y*stride+c.X] = true
+		})
+	}
+	for _, c := range b.obstacles.cells {
+		seen[c.Y*stride+c.X] = true
+	}
+	h := g.s.Head()
+	reach := make([]bool, len(seen))
+	queue := []Cell{h}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		reach[c.Y*stride+c.X] = true
+		for d := 0; d < 4; d++ {
+			x, y := b.neighbor(c.X, c.Y, d)
+			if seen[y*stride+x] || b.crossesWall(c.X, c.Y, x, y) {
+				continue
+			}
+			seen[y*stride+x] = true
+			queue = append(queue, Cell{x, y})
+		}
+	}
+	return reach
+}
@@ -0,0 +1,166 @@
+package game
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed levels/*.txt
+var builtinLevels embed.FS
+
+// Level is an arena layout. In a level file every line is a row of the
+// board and every character a cell:
+//
+//	# wall
+//	. empty
+//	S start of the snake's head, the body extends to the left
+//	F food spawn zone, without any F food spawns anywhere
+//	  (a space) outside the board
+//
+// Spaces mask the board into any shape, like a circle: the cells
+// outside it are walls the snake crashes into, also after wrapping
+// around the edge. Rows may be shorter than the widest row, the cells
+// missing are outside the board.
+type Level struct {
+	Name string
+	// cellsX and cellsY are the board size in the sense of
+	// Rules.CellsX and Rules.CellsY.
+	cellsX, cellsY int
+	// walls are the walls of the level and the cells outside the board
+	walls []Cell
+	// outside marks the cells outside the board, indexed like
+	// foodZone, nil if the board is not masked
+	outside  []bool
+	start    Cell
+	hasStart bool
+	food     []Cell
+}
+
+// ParseLevel reads a level from r.
+func ParseLevel(name string, r io.Reader) (*Level, error) {
+	l := &Level{Name: name}
+	var rows []string
+	width := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if len(line) > width {
+			width = len(line)
+		}
+		rows = append(rows, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	height := len(rows)
+	if width < 2 || height < 2 {
+		return nil, fmt.Errorf("%s: level is smaller than 2x2 cells", name)
+	}
+	for y, line := range rows {
+		line += strings.Repeat(" ", width-len(line))
+		for x, c := range line {
+			switch c {
+			case '#':
+				l.walls = append(l.walls, Cell{x, y})
+			case ' ':
+				if l.outside == nil {
+					l.outside = make([]bool, width*height)
+				}
+				l.outside[y*width+x] = true
+				l.walls = append(l.walls, Cell{x, y})
+			case '.':
+			case 'S':
+				if l.hasStart {
+					return nil, fmt.Errorf("%s:%d: second snake start", name, y+1)
+				}
+				l.start, l.hasStart = Cell{x, y}, true
+			case 'F':
+				l.food = append(l.food, Cell{x, y})
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown cell %q", name, y+1, c)
+			}
+		}
+	}
+	// the board reaches from 0 to cellsX inclusive
+	l.cellsX, l.cellsY = width-1, height-1
+	return l, nil
+}
+
+// LoadLevel loads a built-in level by name, or else the level file at
+// path.
+func LoadLevel(path string) (*Level, error) {
+	if f, err := builtinLevels.Open("levels/" + path + ".txt"); err == nil {
+		defer f.Close()
+		return ParseLevel(path, f)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLevel(path, f)
+}
+
+// BuiltinLevels returns the names of the built-in levels.
+func BuiltinLevels() []string {
+	entries, _ := builtinLevels.ReadDir("levels")
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = strings.TrimSuffix(e.Name(), path.Ext(e.Name()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Size returns the board size of the level in the sense of Rules.CellsX
+// and Rules.CellsY.
+func (l *Level) Size() (cellsX, cellsY int) {
+	return l.cellsX, l.cellsY
+}
+
+// Playable reports whether the cell (x, y) is inside the board of the
+// level and no wall.
+func (l *Level) Playable(x, y int) bool {
+	if x < 0 || y < 0 || x > l.cellsX || y > l.cellsY {
+		return false
+	}
+	if l.outside != nil && l.outside[y*(l.cellsX+1)+x] {
+		return false
+	}
+	for _, c := range l.walls {
+		if c.X == x && c.Y == y {
+			return false
+		}
+	}
+	return true
+}
+
+// foodZone returns the cells food may spawn on, indexed like reachable,
+// or nil if food may spawn anywhere. On masked boards without a food
+// zone food spawns anywhere inside the board.
+func (l *Level) foodZone() []bool {
+	if l == nil || (len(l.food) == 0 && l.outside == nil) {
+		return nil
+	}
+	stride := l.cellsX + 1
+	zone := make([]bool, stride*(l.cellsY+1))
+	if len(l.food) == 0 {
+		for i, out := range l.outside {
+			zone[i] = !out
+		}
+		return zone
+	}
+	for _, c := range l.food {
+		zone[c.Y*stride+c.X] = true
+	}
+	return zone
+}
@@ -0,0 +1,68 @@
+package game
+
+// spawnClearance is the number of cells around the snake's start kept
+// free of obstacles, and the number of cells ahead of its head.
+const spawnClearance = 2
+
+// obstacles are impassable blocks on the board.
+type obstacles struct {
+	// blocked marks the blocked cells, indexed by y*stride+x
+	blocked []bool
+	stride  int
+	cells   []Cell
+}
+
+func newObstacles(cellsX, cellsY int) *obstacles {
+	return &obstacles{
+		stride:  cellsX + 1,
+		blocked: make([]bool, (cellsX+1)*(cellsY+1)),
+	}
+}
+
+// at reports whether the cell is blocked.
+func (o *obstacles) at(x, y int) bool {
+	return o.blocked[y*o.stride+x]
+}
+
+func (o *obstacles) add(x, y int) {
+	if o.at(x, y) {
+		return
+	}
+	o.blocked[y*o.stride+x] = true
+	o.cells = append(o.cells, Cell{x, y})
+}
+
+func (o *obstacles) clear() {
+	for _, c := range o.cells {
+		o.blocked[c.Y*o.stride+c.X] = false
+	}
+	o.cells = o.cells[:0]
+}
+
+// spawnObstacles places n obstacles on random cells, keeping the area
+// around the snake and the cells ahead of its head free.
+func (g *Game) spawnObstacles(n int) {
+	b := g.board
+	// spawn reports whether the cell is close to the start of s
+	spawn := func(s *Snake, x, y int) bool {
+		if s == nil {
+			return false
+		}
+		h := s.Head()
+		return x >= h.X-s.Len()-spawnClearance && x <= h.X+spawnClearance*4 && abs(y-h.Y) <= spawnClearance
+	}
+	for i := 0; i < n; i++ {
+		// give up on crowded boards instead of looping forever
+		for try := 0; try < 100; try++ {
+			x, y := g.rng.Intn(b.cellsX+1), g.rng.Intn(b.cellsY+1)
+			if spawn(g.s, x, y) || spawn(g.rival, x, y) {
+				continue
+			}
+			if b.obstacles.at(x, y) || g.s.Occupies(x, y) || g.rival.Occupies(x, y) {
+				continue
+			}
+			b.obstacles.add(x, y)
+			break
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package game
+
+import "testing"
+
+// TestSpawnObstaclesWholeBoard fills a board without a snake with
+// obstacles and checks every cell, the last column and row included,
+// can be blocked.
+func TestSpawnObstaclesWholeBoard(t *testing.T) {
+	g := New(Rules{CellsX: 4, CellsY: 3, InitialLength: 1, Speed: 1, SpeedUp: 10000}, 1)
+	g.s = nil
+	g.board.obstacles.clear()
+	g.spawnObstacles(200)
+	for y := 0; y <= 3; y++ {
+		for x := 0; x <= 4; x++ {
+			if !g.board.obstacles.at(x, y) {
+				t.Errorf("no obstacle on (%d, %d)", x, y)
+			}
+		}
+	}
+}
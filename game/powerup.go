@@ -0,0 +1,92 @@
+package game
+
+// PowerupKind is the effect of a power-up.
+type PowerupKind int
+
+const (
+	// PowerupFast shortens the step interval.
+	PowerupFast PowerupKind = iota
+	// PowerupSlow stretches the step interval.
+	PowerupSlow
+	// PowerupShrink removes tail segments right away.
+	PowerupShrink
+	// PowerupGhost lets the snake pass through its own body.
+	PowerupGhost
+	// PowerupKinds is the number of power-up kinds.
+	PowerupKinds
+)
+
+var powerupNames = [...]string{"FAST", "SLOW", "SHRINK", "GHOST"}
+
+func (k PowerupKind) String() string {
+	if k < 0 || k >= PowerupKinds {
+		return "UNKNOWN"
+	}
+	return powerupNames[k]
+}
+
+const (
+	// powerupInterval is the number of frames between two power-ups.
+	powerupInterval = 15 * 60
+	// powerupLife is the number of frames a power-up stays on the board.
+	powerupLife = 8 * 60
+	// effectDuration is the number of frames a timed effect lasts.
+	effectDuration = 5 * 60
+	// shrinkSegments is the number of tail segments a shrink removes.
+	shrinkSegments = 3
+)
+
+// Powerup is a pickup on the board. Its Food has no kind, the Life of
+// the Food is the number of frames left before it disappears.
+type Powerup struct {
+	Food
+	Effect PowerupKind
+}
+
+// powerupAt reports whether the power-up on the board is at the cell.
+func (g *Game) powerupAt(x, y int) bool {
+	return g.powerup != nil && g.powerup.X == x && g.powerup.Y == y
+}
+
+// updatePowerups spawns and expires power-ups, applies a picked up one
+// and counts down the running effect.
+func (g *Game) updatePowerups() {
+	if g.effectLeft > 0 {
+		g.effectLeft--
+	}
+	if g.powerup == nil {
+		if g.frame%powerupInterval == 0 {
+			g.powerup = &Powerup{
+				Food:   Food{X: -1, Y: -1, Life: powerupLife},
+				Effect: PowerupKind(g.rng.Intn(int(PowerupKinds))),
+			}
+			g.respawn(&g.powerup.Food)
+		}
+		return
+	}
+	g.powerup.Life--
+	if g.powerup.Life <= 0 {
+		g.powerup = nil
+		return
+	}
+	h := g.s.Head()
+	if !g.powerupAt(h.X, h.Y) {
+		return
+	}
+	kind := g.powerup.Effect
+	g.powerup = nil
+	g.emit(Event{Kind: EventPowerup, X: h.X, Y: h.Y})
+	if kind == PowerupShrink {
+		for i := 0; i < shrinkSegments && g.s.Len() > g.rules.InitialLength; i++ {
+			g.s.pop()
+		}
+		return
+	}
+	g.effect = kind
+	g.effectLeft = effectDuration
+}
+
+// effectActive reports whether the timed effect kind is running.
+func (g *Game) effectActive(kind PowerupKind) bool {
+	return g.effectLeft > 0 && g.effect == kind
+}
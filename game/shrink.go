@@ -0,0 +1,148 @@
+package game
+
+const (
+	// ShrinkInterval is the number of frames between two shrinks of the
+	// arena with the Shrink rule.
+	ShrinkInterval = 30 * 60
+	// ShrinkWarning is the number of frames before a shrink the ring of
+	// cells closing is marked for.
+	ShrinkWarning = 5 * 60
+	// minArena is the smallest width and height the arena shrinks to.
+	minArena = 8
+	// shrinkBonus are the points for surviving the first shrink, every
+	// further shrink is worth as much more.
+	shrinkBonus = 1000
+)
+
+// Ring returns the ring of the board the cell (x, y) is on, the number
+// of cells to the nearest board edge. The arena shrinks by one ring at
+// a time.
+func (g *Game) Ring(x, y int) int {
+	b := g.board
+	ring := x
+	for _, d := range []int{b.cellsX - x, y, b.cellsY - y} {
+		if d < ring {
+			ring = d
+		}
+	}
+	return ring
+}
+
+// maxShrinks returns the number of times the arena shrinks before it
+// reaches its smallest size.
+func (g *Game) maxShrinks() int {
+	b := g.board
+	side := b.cellsX
+	if b.cellsY < side {
+		side = b.cellsY
+	}
+	if n := (side + 1 - minArena) / 2; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// NextShrink returns the ring closing on the next shrink of the arena
+// and the number of frames until it closes, which happens on the first
+// step after. ok is false without the Shrink rule or once the arena
+// reached its smallest size.
+func (g *Game) NextShrink() (ring int, left int64, ok bool) {
+	if !g.rules.Shrink || g.shrunk >= g.maxShrinks() {
+		return 0, 0, false
+	}
+	left = int64(g.shrunk+1)*ShrinkInterval - g.frame
+	if left < 0 {
+		left = 0
+	}
+	return g.shrunk, left, true
+}
+
+// Shrunk returns the number of rings the arena shrank by.
+func (g *Game) Shrunk() int {
+	return g.shrunk
+}
+
+// shrink closes the rings of the arena due by the current frame. Their
+// cells turn into obstacles, food, poison and power-ups on them move
+// or vanish. The walls cut off the snakes behind the first segment on
+// them, snakes with the head on them are caught, see caught. The
+// snakes surviving score a bonus, except in tron runs scoring the time
+// survived anyway.
+func (g *Game) shrink() {
+	b := g.board
+	for g.shrunk < g.maxShrinks() && g.frame >= int64(g.shrunk+1)*ShrinkInterval {
+		ring := g.shrunk
+		g.shrunk++
+		for y := ring; y <= b.cellsY-ring; y++ {
+			for x := ring; x <= b.cellsX-ring; x++ {
+				if g.Ring(x, y) == ring {
+					b.obstacles.add(x, y)
+				}
+			}
+		}
+		g.cut(g.s)
+		g.cut(g.rival)
+		for _, f := range g.foods {
+			if g.closed(f.X, f.Y) {
+				g.respawn(f)
+			}
+		}
+		if f := g.forbidden; f != nil && g.closed(f.X, f.Y) {
+			g.respawn(f)
+		}
+		poisons := g.poisons[:0]
+		for _, p := range g.poisons {
+			if !g.closed(p.X, p.Y) {
+				poisons = append(poisons, p)
+			}
+		}
+		g.poisons = poisons
+		if p := g.powerup; p != nil && g.closed(p.X, p.Y) {
+			g.powerup = nil
+		}
+		var points int64
+		if bonus := int64(g.shrunk) * shrinkBonus; !g.rules.Tron {
+			if !g.caught(g.s) {
+				g.addPoints(bonus)
+				points = bonus
+			}
+			if g.rival != nil && !g.rules.Coop && !g.caught(g.rival) {
+				g.rivalPoints += bonus
+			}
+		}
+		g.emit(Event{Kind: EventShrink, Points: points})
+	}
+}
+
+// closed reports whether the cell (x, y) is on a ring the arena shrank
+// by. Food taken off the board is on no ring.
+func (g *Game) closed(x, y int) bool {
+	return x >= 0 && y >= 0 && g.Ring(x, y) < g.shrunk
+}
+
+// caught reports whether the head of s is on a ring the arena shrank
+// by.
+func (g *Game) caught(s *Snake) bool {
+	if s == nil || g.shrunk == 0 {
+		return false
+	}
+	h := s.Head()
+	return g.closed(h.X, h.Y)
+}
+
+// cut removes the segments of s from the first one behind the head on
+// a ring the arena shrank by to the tail. Snakes caught keep their
+// body for the crash.
+func (g *Game) cut(s *Snake) {
+	if s == nil || g.caught(s) {
+		return
+	}
+	for i := 1; i < s.Len(); i++ {
+		if c := s.At(i); g.closed(c.X, c.Y) {
+			for s.Len() > i {
+				s.pop()
+			}
+			return
+		}
+	}
+}
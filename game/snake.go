@@ -0,0 +1,188 @@
+package game
+
+// Cell is a position on the board.
+type Cell struct {
+	X, Y int
+}
+
+// maxPending is the number of turns buffered between two steps.
+const maxPending = 2
+
+// Snake keeps the cells of a snake in a ring buffer, so moving is a
+// push of the new head and a pop of the tail. The number of segments on
+// each cell is counted in occupied for constant time collision checks.
+type Snake struct {
+	// cells is the ring buffer, the tail is at cells[tail] and the
+	// head n-1 cells after it.
+	cells []Cell
+	tail  int
+	n     int
+
+	// occupied counts the segments on each cell, indexed by
+	// y*stride+x.
+	occupied []int
+	stride   int
+
+	// direction is the direction the snake moved in on the last step
+	direction int
+	// pending are the turns to apply on the next steps, one per step
+	pending []int
+	// wrapped is set if the last move wrapped around the board edge
+	wrapped bool
+	// grow is the number of steps the tail stays in place
+	grow int
+	// left is the cell the tail was on before the last step
+	left Cell
+}
+
+// newSnake creates a snake with its head on start and the body
+// extending to the left.
+func newSnake(b *board, initialLength int, start Cell) *Snake {
+	s := &Snake{
+		cells:    make([]Cell, 0, 64),
+		stride:   b.cellsX + 1,
+		occupied: make([]int, (b.cellsX+1)*(b.cellsY+1)),
+		grow:     1,
+	}
+	s.cells = s.cells[:cap(s.cells)]
+	x, y := start.X, start.Y
+	for i := initialLength - 1; i >= 0; i-- {
+		s.push(Cell{((x-i)%s.stride + s.stride) % s.stride, y})
+	}
+	s.left = s.At(s.n - 1)
+	return s
+}
+
+// push adds c as the new head.
+func (s *Snake) push(c Cell) {
+	if s.n == len(s.cells) {
+		cells := make([]Cell, 2*len(s.cells))
+		for i := 0; i < s.n; i++ {
+			cells[i] = s.cells[(s.tail+i)%len(s.cells)]
+		}
+		s.cells = cells
+		s.tail = 0
+	}
+	s.cells[(s.tail+s.n)%len(s.cells)] = c
+	s.n++
+	s.occupied[c.Y*s.stride+c.X]++
+}
+
+// pop removes the tail.
+func (s *Snake) pop() {
+	c := s.cells[s.tail]
+	s.occupied[c.Y*s.stride+c.X]--
+	s.tail = (s.tail + 1) % len(s.cells)
+	s.n--
+}
+
+// At returns the i-th cell counted from the head.
+func (s *Snake) At(i int) Cell {
+	return s.cells[(s.tail+s.n-1-i)%len(s.cells)]
+}
+
+// Head returns the cell of the head.
+func (s *Snake) Head() Cell {
+	return s.At(0)
+}
+
+// Len returns the number of cells the snake takes.
+func (s *Snake) Len() int {
+	return s.n
+}
+
+// Previous returns the cell the i-th cell counted from the head was on
+// before the last step. Every segment takes the place of the one in
+// front of it, so this is the next cell towards the tail, and for the
+// tail the cell it left.
+func (s *Snake) Previous(i int) Cell {
+	if i+1 < s.n {
+		return s.At(i + 1)
+	}
+	return s.left
+}
+
+// Growing returns the number of steps the tail stays in place.
+func (s *Snake) Growing() int {
+	return s.grow
+}
+
+// Direction returns the direction the snake moved in on the last step:
+// 0 right, 1 down, 2 left and 3 up.
+func (s *Snake) Direction() int {
+	return s.direction
+}
+
+// Each calls fn for every cell from the head to the tail.
+func (s *Snake) Each(fn func(c Cell)) {
+	for i := 0; i < s.n; i++ {
+		fn(s.At(i))
+	}
+}
+
+// Occupies reports whether any part of the snake is on the given cell.
+// A nil snake occupies nothing.
+func (s *Snake) Occupies(x, y int) bool {
+	if s == nil || x < 0 || x >= s.stride || y < 0 || y*s.stride+x >= len(s.occupied) {
+		return false
+	}
+	return s.occupied[y*s.stride+x] > 0
+}
+
+// turn queues a turn for one of the next steps. Turns are checked
+// against the last queued turn, or the direction the snake is moving
+// in if there is none, so the snake cannot reverse into itself. A turn
+// back to the current direction while another turn is pending is
+// dropped, so two quick taps result in a single turn. turn reports
+// whether the turn was queued.
+func (s *Snake) turn(direction int) bool {
+	direction %= 4
+	last := s.direction
+	if len(s.pending) > 0 {
+		last = s.pending[len(s.pending)-1]
+	}
+	switch {
+	case len(s.pending) >= maxPending:
+	case direction == last, direction == (last+2)%4:
+	case len(s.pending) > 0 && direction == s.direction:
+	default:
+		s.pending = append(s.pending, direction)
+		return true
+	}
+	return false
+}
+
+// move applies the next pending turn and moves the snake by one cell.
+// While growing the tail stays in place.
+func (s *Snake) move(b *board) {
+	if len(s.pending) > 0 {
+		next := s.pending[0]
+		s.pending = s.pending[1:]
+		if next != (s.direction+2)%4 {
+			s.direction = next
+		}
+	}
+	h := s.Head()
+	x, y := b.neighbor(h.X, h.Y, s.direction)
+	s.wrapped = abs(x-h.X)+abs(y-h.Y) != 1
+	s.left = s.At(s.n - 1)
+	if s.grow > 0 {
+		s.grow--
+	} else {
+		s.pop()
+	}
+	s.push(Cell{x, y})
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// alive reports whether the head is on a cell free of the body.
+func (s *Snake) alive() bool {
+	h := s.Head()
+	return s.occupied[h.Y*s.stride+h.X] == 1
+}
@@ -0,0 +1,47 @@
+package game
+
+import "testing"
+
+// TestMoveScripted queues turns before each step of a snake crossing
+// the board edges and checks where the head ends up.
+func TestMoveScripted(t *testing.T) {
+	b := &board{cellsX: 9, cellsY: 9}
+	s := snakeOn(b, 0, Cell{7, 5}, Cell{8, 5}, Cell{9, 5})
+	steps := []struct {
+		turns   []int
+		head    Cell
+		wrapped bool
+	}{
+		{nil, Cell{0, 5}, true},
+		{[]int{3, 2}, Cell{0, 4}, false},
+		{nil, Cell{9, 4}, true},
+		{[]int{1, 0}, Cell{9, 5}, false},
+		{nil, Cell{0, 5}, true},
+		// mashing the opposite and the current direction does nothing
+		{[]int{2, 0}, Cell{1, 5}, false},
+		{[]int{3, 2}, Cell{1, 4}, false},
+		{nil, Cell{0, 4}, false},
+		{[]int{3}, Cell{0, 3}, false},
+		// a tap back to the current direction after a turn is dropped
+		{[]int{2, 3}, Cell{9, 3}, true},
+		{nil, Cell{8, 3}, false},
+		{[]int{1}, Cell{8, 4}, false},
+		{[]int{0, 1}, Cell{9, 4}, false},
+		{nil, Cell{0, 4}, true},
+	}
+	for i, step := range steps {
+		for _, d := range step.turns {
+			s.turn(d)
+		}
+		s.move(b)
+		if h := s.Head(); h != step.head {
+			t.Fatalf("step %d: head on %v, want %v", i, h, step.head)
+		}
+		if s.wrapped != step.wrapped {
+			t.Errorf("step %d: wrapped %v, want %v", i, s.wrapped, step.wrapped)
+		}
+		if !s.alive() {
+			t.Fatalf("step %d: the snake ran into itself on %v", i, s.Head())
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package game
+
+import (
+	"errors"
+)
+
+// Snapshot is the state of a run, enough to continue it with the same
+// rules. It does not include the state of the random numbers.
+type Snapshot struct {
+	// Level is the index of the campaign level
+	Level      int `json:"level"`
+	LevelEaten int `json:"level_eaten"`
+
+	Frame       int64 `json:"frame"`
+	LastStep    int64 `json:"last_step,omitempty"`
+	NextStep    int64 `json:"next_step"`
+	Points      int64 `json:"points"`
+	RivalPoints int64 `json:"rival_points"`
+	Eaten       int   `json:"eaten"`
+	MaxLength   int   `json:"max_length"`
+	Reversed    int   `json:"reversed"`
+	AIRespawn   int   `json:"ai_respawn"`
+	Lives       int   `json:"lives,omitempty"`
+	Shrunk      int   `json:"shrunk,omitempty"`
+	Gauntlet    int   `json:"gauntlet,omitempty"`
+	Effect      int   `json:"effect"`
+	EffectLeft  int   `json:"effect_left"`
+	Combo       int   `json:"combo,omitempty"`
+	LastEat     int64 `json:"last_eat,omitempty"`
+
+	Snake     savedSnake  `json:"snake"`
+	Rival     *savedSnake `json:"rival,omitempty"`
+	Foods     []savedFood `json:"foods"`
+	Forbidden *savedFood  `json:"forbidden,omitempty"`
+	Poisons   []savedFood `json:"poisons,omitempty"`
+	Powerup   *savedFood  `json:"powerup,omitempty"`
+	Obstacles [][2]int    `json:"obstacles,omitempty"`
+}
+
+// savedSnake is a snake in a snapshot.
+type savedSnake struct {
+	// Body are the cells from the head to the tail
+	Body      [][2]int `json:"body"`
+	Direction int      `json:"direction"`
+	Grow      int      `json:"grow"`
+	// Pending are the turns queued for the next steps and Left the cell
+	// the tail left on the last step
+	Pending []int   `json:"pending,omitempty"`
+	Left    *[2]int `json:"left,omitempty"`
+}
+
+// savedFood is a food, poison or power-up in a snapshot. Kind is the
+// name of the food type or the power-up kind.
+type savedFood struct {
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Kind string `json:"kind,omitempty"`
+	Life int    `json:"life,omitempty"`
+}
+
+func saveSnake(s *Snake) savedSnake {
+	sv := savedSnake{Direction: s.direction, Grow: s.grow, Left: &[2]int{s.left.X, s.left.Y}}
+	s.Each(func(c Cell) {
+		sv.Body = append(sv.Body, [2]int{c.X, c.Y})
+	})
+	sv.Pending = append(sv.Pending, s.pending...)
+	return sv
+}
+
+func (sv savedSnake) restore(b *board) *Snake {
+	s := newSnake(b, 0, Cell{})
+	for i := len(sv.Body) - 1; i >= 0; i-- {
+		s.push(Cell{sv.Body[i][0], sv.Body[i][1]})
+	}
+	s.direction, s.grow = sv.Direction, sv.Grow
+	s.pending = append(s.pending, sv.Pending...)
+	s.left = s.At(s.n - 1)
+	if sv.Left != nil {
+		s.left = Cell{sv.Left[0], sv.Left[1]}
+	}
+	return s
+}
+
+func saveFood(f *Food) savedFood {
+	sf := savedFood{X: f.X, Y: f.Y, Life: f.Life}
+	if f.Kind != nil {
+		sf.Kind = f.Kind.Name
+	}
+	return sf
+}
+
+func (sf savedFood) restore() *Food {
+	f := &Food{X: sf.X, Y: sf.Y, Life: sf.Life}
+	for _, t := range FoodTypes {
+		if t.Name == sf.Kind {
+			f.Kind = t
+		}
+	}
+	return f
+}
+
+// Snapshot returns the state of the run.
+func (g *Game) Snapshot() Snapshot {
+	sv := Snapshot{
+		Level:       g.level,
+		LevelEaten:  g.levelEaten,
+		Frame:       g.frame,
+		LastStep:    g.lastStep,
+		NextStep:    g.nextStep,
+		Points:      g.points,
+		RivalPoints: g.rivalPoints,
+		Eaten:       g.eaten,
+		MaxLength:   g.maxLength,
+		Reversed:    g.reversed,
+		AIRespawn:   g.aiRespawn,
+		Lives:       g.lives,
+		Shrunk:      g.shrunk,
+		Gauntlet:    g.gauntletSpawns,
+		Effect:      int(g.effect),
+		EffectLeft:  g.effectLeft,
+		Combo:       g.combo,
+		LastEat:     g.lastEat,
+		Snake:       saveSnake(g.s),
+	}
+	if g.rival != nil {
+		r := saveSnake(g.rival)
+		sv.Rival = &r
+	}
+	for _, f := range g.foods {
+		sv.Foods = append(sv.Foods, saveFood(f))
+	}
+	if g.forbidden != nil {
+		f := saveFood(g.forbidden)
+		sv.Forbidden = &f
+	}
+	for _, p := range g.poisons {
+		sv.Poisons = append(sv.Poisons, saveFood(p))
+	}
+	if p := g.powerup; p != nil {
+		sv.Powerup = &savedFood{X: p.X, Y: p.Y, Kind: p.Effect.String(), Life: p.Life}
+	}
+	for _, c := range g.board.obstacles.cells {
+		sv.Obstacles = append(sv.Obstacles, [2]int{c.X, c.Y})
+	}
+	return sv
+}
+
+// Restore replaces the run with the snapshot sv taken with the same
+// rules.
+func (g *Game) Restore(sv Snapshot) error {
+	b := g.board
+	if campaign := g.rules.Campaign; len(campaign) > 0 && (sv.Level < 0 || sv.Level >= len(campaign)) {
+		return errors.New("the snapshot is of an unknown campaign level")
+	}
+	if len(sv.Snake.Body) == 0 {
+		return errors.New("the snapshot has no snake")
+	}
+	cells := append([][2]int(nil), sv.Snake.Body...)
+	if sv.Rival != nil {
+		cells = append(cells, sv.Rival.Body...)
+	}
+	cells = append(cells, sv.Obstacles...)
+	for _, c := range cells {
+		if c[0] < 0 || c[0] > b.cellsX || c[1] < 0 || c[1] > b.cellsY {
+			return errors.New("the snapshot is of another board")
+		}
+	}
+	turns := sv.Snake.Pending
+	if sv.Rival != nil {
+		turns = append(append([]int(nil), turns...), sv.Rival.Pending...)
+	}
+	for _, d := range turns {
+		if d < 0 || d > 3 {
+			return errors.New("the snapshot has an invalid turn")
+		}
+	}
+
+	g.over, g.complete = false, false
+	g.winner = 0
+	g.won = false
+	g.level, g.levelEaten = sv.Level, sv.LevelEaten
+	g.frame, g.nextStep = sv.Frame, sv.NextStep
+	g.lastStep = sv.LastStep
+	if g.lastStep == 0 {
+		// saved by a version without it
+		g.lastStep = g.frame
+	}
+	g.points, g.rivalPoints = sv.Points, sv.RivalPoints
+	g.eaten, g.maxLength = sv.Eaten, sv.MaxLength
+	g.reversed, g.aiRespawn = sv.Reversed, sv.AIRespawn
+	g.lives, g.shrunk = sv.Lives, sv.Shrunk
+	g.gauntletSpawns = sv.Gauntlet
+	g.effect, g.effectLeft = PowerupKind(sv.Effect), sv.EffectLeft
+	g.combo, g.lastEat = sv.Combo, sv.LastEat
+
+	g.s = sv.Snake.restore(b)
+	g.rival = nil
+	if sv.Rival != nil {
+		g.rival = sv.Rival.restore(b)
+	}
+	g.foods = g.foods[:0]
+	for _, f := range sv.Foods {
+		g.foods = append(g.foods, f.restore())
+	}
+	g.forbidden = nil
+	if sv.Forbidden != nil {
+		g.forbidden = sv.Forbidden.restore()
+	}
+	g.poisons = nil
+	for _, p := range sv.Poisons {
+		g.poisons = append(g.poisons, p.restore())
+	}
+	g.powerup = nil
+	if p := sv.Powerup; p != nil {
+		for k := PowerupKind(0); k < PowerupKinds; k++ {
+			if k.String() == p.Kind {
+				g.powerup = &Powerup{Food: Food{X: p.X, Y: p.Y, Life: p.Life}, Effect: k}
+			}
+		}
+	}
+	b.obstacles.clear()
+	for _, c := range sv.Obstacles {
+		b.obstacles.add(c[0], c[1])
+	}
+	return nil
+}
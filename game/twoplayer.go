@@ -0,0 +1,114 @@
+package game
+
+import (
+	"math"
+)
+
+// initRival places the snake of player two mirrored to player one.
+func (g *Game) initRival() {
+	h := g.s.Head()
+	start := Cell{g.board.cellsX - h.X, g.board.cellsY - h.Y}
+	g.rival = newSnake(g.board, g.rules.InitialLength, start)
+}
+
+// crashed reports whether s ran into a wall, an obstacle, itself or the
+// other snake on its last step.
+func (g *Game) crashed(s, other *Snake) bool {
+	return g.crashCause(s, other) != CauseNone
+}
+
+// crashCause returns what s ran into on its last step, CauseNone if it
+// did not crash.
+func (g *Game) crashCause(s, other *Snake) Cause {
+	h := s.Head()
+	switch {
+	case g.caught(s):
+		return CauseArena
+	case g.rules.Walls && s.wrapped:
+		return CauseWall
+	case g.board.obstacles.at(h.X, h.Y):
+		return CauseObstacle
+	case other.Occupies(h.X, h.Y):
+		return CauseRival
+	case s == g.s && g.effectActive(PowerupGhost):
+		return CauseNone
+	case !s.alive() && !(g.rules.WrapGrace && s.wrapped):
+		return CauseSelf
+	}
+	return CauseNone
+}
+
+// headOn decides the crashes one of player one and two of player two
+// if the heads of both snakes met on the last step, moving onto the
+// same cell or through each other. With the LongerWins rule the longer
+// snake survives.
+func (g *Game) headOn(one, two Cause) (Cause, Cause) {
+	if !g.rules.LongerWins || one != CauseRival || two != CauseRival {
+		return one, two
+	}
+	s, r := g.s, g.rival
+	a, b := s.Head(), r.Head()
+	if a != b && (s.Len() < 2 || r.Len() < 2 || a != r.At(1) || b != s.At(1)) {
+		return one, two
+	}
+	switch {
+	case s.Len() > r.Len():
+		return CauseNone, two
+	case r.Len() > s.Len():
+		return one, CauseNone
+	}
+	return one, two
+}
+
+// rivalEat lets the rival eat the food at its head. In co-op runs the
+// points go to the shared score.
+func (g *Game) rivalEat() {
+	r := g.rules
+	h := g.rival.Head()
+	i := g.foodAt(h.X, h.Y)
+	if i == -1 {
+		return
+	}
+	f := g.foods[i]
+	points := &g.rivalPoints
+	if r.Coop {
+		g.addPoints(f.Kind.Points)
+		points = &g.points
+	} else {
+		g.rivalPoints += f.Kind.Points
+	}
+	g.emit(Event{Kind: EventEat, Player: 1, X: f.X, Y: f.Y, Points: f.Kind.Points, Food: f.Kind})
+	if r.GrowPerFood > 0 {
+		g.rival.grow += r.GrowPerFood
+	} else {
+		g.rival.grow = int(math.Log10(float64(*points)))
+	}
+	g.rival.grow += f.Kind.Grow
+	if r.Harvest > 0 {
+		g.foods = append(g.foods[:i], g.foods[i+1:]...)
+		if g.waveCleared() {
+			g.spawnWave(r.Harvest)
+		}
+		return
+	}
+	g.renewFood(f)
+}
+
+// twoPlayerOver ends a two-player game. The surviving player wins, if
+// both crashed the higher score does.
+func (g *Game) twoPlayerOver(one, two Cause) {
+	oneCrashed, twoCrashed := one != CauseNone, two != CauseNone
+	switch {
+	case oneCrashed && !twoCrashed:
+		g.winner = 2
+	case twoCrashed && !oneCrashed:
+		g.winner = 1
+	case g.points > g.rivalPoints:
+		g.winner = 1
+	case g.rivalPoints > g.points:
+		g.winner = 2
+	default:
+		g.winner = 0
+	}
+	g.gameOver(one)
+}
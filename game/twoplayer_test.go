@@ -0,0 +1,75 @@
+package game
+
+import "testing"
+
+// snakeOn returns a snake on cells, from the tail to the head, moving in
+// direction.
+func snakeOn(b *board, direction int, cells ...Cell) *Snake {
+	s := newSnake(b, 1, cells[0])
+	for _, c := range cells[1:] {
+		s.push(c)
+	}
+	s.direction = direction
+	s.grow = 0
+	return s
+}
+
+// headOnGame returns a two-player game with a snake of one cells for
+// player one moving right onto the cell (6, 5) and one of two cells for
+// player two moving left onto it, or through player one if apart is
+// false.
+func headOnGame(t *testing.T, longerWins bool, one, two int, apart bool) *Game {
+	t.Helper()
+	g := New(Rules{CellsX: 20, CellsY: 10, InitialLength: 3, Speed: 1, SpeedUp: 10000, TwoPlayer: true, LongerWins: longerWins}, 1)
+	b := g.board
+	var cells []Cell
+	for i := one - 1; i >= 0; i-- {
+		cells = append(cells, Cell{5 - i, 5})
+	}
+	g.s = snakeOn(b, 0, cells...)
+	first := 7
+	if !apart {
+		first = 6
+	}
+	cells = nil
+	for i := two - 1; i >= 0; i-- {
+		cells = append(cells, Cell{first + i, 5})
+	}
+	g.rival = snakeOn(b, 2, cells...)
+	g.foods = nil
+	return g
+}
+
+func TestHeadOn(t *testing.T) {
+	tests := []struct {
+		name       string
+		longerWins bool
+		one, two   int
+		apart      bool
+		oneCause   Cause
+		winner     int
+	}{
+		{"both crash, equal score", false, 5, 3, true, CauseRival, 0},
+		{"longer wins, player one longer", true, 5, 3, true, CauseNone, 1},
+		{"longer wins, player two longer", true, 3, 5, true, CauseRival, 2},
+		{"longer wins, same length", true, 4, 4, true, CauseRival, 0},
+		{"longer wins, moving through", true, 5, 3, false, CauseNone, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := headOnGame(t, tt.longerWins, tt.one, tt.two, tt.apart)
+			for i := 0; i < 10 && !g.Over(); i++ {
+				g.Tick()
+			}
+			if !g.Over() {
+				t.Fatal("the game did not end on the head-on crash")
+			}
+			if g.Cause() != tt.oneCause {
+				t.Errorf("player one crashed into %v, want %v", g.Cause(), tt.oneCause)
+			}
+			if g.Winner() != tt.winner {
+				t.Errorf("player %d won, want %d", g.Winner(), tt.winner)
+			}
+		})
+	}
+}
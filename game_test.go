@@ -0,0 +1,72 @@
+package snake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+func TestValidateHarvest(t *testing.T) {
+	tests := []struct {
+		name    string
+		harvest int
+		twoUp   bool
+		ok      bool
+	}{
+		{"off", 0, false, true},
+		{"one", 1, false, true},
+		{"every free cell", 21, false, true},
+		{"more than free", 22, false, false},
+		{"every free cell with two snakes", 18, true, true},
+		{"more than free with two snakes", 19, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := DefaultOptions()
+			o.CellsX, o.CellsY = 4, 4
+			o.InitialLength = 3
+			o.Obstacles = 1
+			o.Harvest = tt.harvest
+			o.TwoPlayer = tt.twoUp
+			if err := o.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStatsDuration(t *testing.T) {
+	for _, rate := range []int{30, DefaultTickRate, 120, 144} {
+		g := &Game{
+			sim:   game.New(game.Rules{CellsX: 20, CellsY: 10, InitialLength: 3, Speed: 1000, SpeedUp: 10000}, 1),
+			clock: newClock(rate),
+		}
+		for i := 0; i < 2*rate; i++ {
+			g.sim.Tick()
+		}
+		if d := g.Stats().Duration; d < 2*time.Second-time.Millisecond || d > 2*time.Second+time.Millisecond {
+			t.Errorf("%d ticks per second: 2 seconds of ticks last %v", rate, d)
+		}
+	}
+}
+
+func TestValidateInitialLength(t *testing.T) {
+	tests := []struct {
+		length int
+		ok     bool
+	}{
+		{0, false},
+		{1, true},
+		{5, true},
+		{6, false},
+	}
+	for _, tt := range tests {
+		o := DefaultOptions()
+		o.CellsX, o.CellsY = 4, 4
+		o.InitialLength = tt.length
+		if err := o.Validate(); (err == nil) != tt.ok {
+			t.Errorf("initial length %d: Validate() = %v, want ok %v", tt.length, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package snake
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// Buttons of a gamepad in the standard layout.
+const (
+	gamepadA     = ebiten.GamepadButton0
+	gamepadStart = ebiten.GamepadButton9
+	gamepadUp    = ebiten.GamepadButton12
+	gamepadDown  = ebiten.GamepadButton13
+	gamepadLeft  = ebiten.GamepadButton14
+	gamepadRight = ebiten.GamepadButton15
+)
+
+// gamepadJustPressed reports whether button was pressed on any gamepad
+// in this frame.
+func gamepadJustPressed(button ebiten.GamepadButton) bool {
+	for _, id := range ebiten.GamepadIDs() {
+		if inpututil.IsGamepadButtonJustPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// gamepadDirection returns the direction the D-pad or the left stick of
+// any gamepad points to. Stick deflections within deadZone are ignored.
+func gamepadDirection(deadZone float64) (int, bool) {
+	for _, id := range ebiten.GamepadIDs() {
+		switch {
+		case ebiten.IsGamepadButtonPressed(id, gamepadRight):
+			return 0, true
+		case ebiten.IsGamepadButtonPressed(id, gamepadDown):
+			return 1, true
+		case ebiten.IsGamepadButtonPressed(id, gamepadLeft):
+			return 2, true
+		case ebiten.IsGamepadButtonPressed(id, gamepadUp):
+			return 3, true
+		}
+		if ebiten.GamepadAxisNum(id) < 2 {
+			continue
+		}
+		x, y := ebiten.GamepadAxis(id, 0), ebiten.GamepadAxis(id, 1)
+		if math.Max(math.Abs(x), math.Abs(y)) <= deadZone {
+			continue
+		}
+		// the stronger axis wins
+		if math.Abs(x) > math.Abs(y) {
+			if x > 0 {
+				return 0, true
+			}
+			return 2, true
+		}
+		if y > 0 {
+			return 1, true
+		}
+		return 3, true
+	}
+	return 0, false
+}
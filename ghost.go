@@ -0,0 +1,117 @@
+package snake
+
+import (
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// ghostAlpha is the opacity of the ghost snake.
+const ghostAlpha = 0.35
+
+// translucent returns c with its opacity scaled by alpha. Colors are
+// alpha-premultiplied, so the color channels are scaled as well.
+func translucent(c color.RGBA, alpha float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * alpha),
+		G: uint8(float64(c.G) * alpha),
+		B: uint8(float64(c.B) * alpha),
+		A: uint8(float64(c.A) * alpha),
+	}
+}
+
+// bestFile returns the path of the replay of the best run in the
+// current mode, next to its high score table. It is empty if the table
+// is kept in memory.
+func (g *Game) bestFile() string {
+	path := g.scoreFile()
+	if path == "" {
+		return ""
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "-best.replay"
+}
+
+// loadBest loads the replay of the best run in the current mode.
+func (g *Game) loadBest() {
+	path := g.bestFile()
+	if path == "" {
+		return
+	}
+	r, err := LoadReplay(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("could not load the best run: %v", err)
+		return
+	}
+	g.best = r
+	g.ghost = nil
+}
+
+// keepBest keeps r as the best run if it beats the best so far. Runs on
+// autopilot, two-player and co-op runs are not kept.
+func (g *Game) keepBest(r *Replay) {
+	if g.options.Autopilot != nil || g.options.TwoPlayer || g.options.Coop {
+		return
+	}
+	if g.best != nil && r.Score <= g.best.Score {
+		return
+	}
+	g.best = r
+	g.ghost = nil
+	if path := g.bestFile(); path != "" {
+		if err := r.Save(path); err != nil {
+			log.Printf("could not save the best run: %v", err)
+		}
+	}
+}
+
+// startGhost starts playing the best run back beside a new run. The
+// ghost run is reused until the best run changes.
+func (g *Game) startGhost() {
+	o := g.options
+	if !o.Ghost || o.TwoPlayer || o.Coop || g.best == nil || g.replay != nil || g.demo {
+		g.ghost = nil
+		return
+	}
+	g.ghostAt = 0
+	if g.ghost != nil {
+		g.ghost.Reset(g.best.Seed)
+		return
+	}
+	rules := g.best.Rules
+	rules.Bot = g.options.Bot
+	g.ghost = game.New(rules, g.best.Seed)
+	x, y := g.ghost.Size()
+	if cx, cy := g.sim.Size(); x != cx || y != cy {
+		// the best run was played on another board
+		g.ghost = nil
+	}
+}
+
+// updateGhost advances the ghost by one frame, in step with the run.
+func (g *Game) updateGhost() {
+	gh := g.ghost
+	if gh == nil {
+		return
+	}
+	if gh.LevelComplete() {
+		gh.NextLevel()
+		return
+	}
+	gh.Tick(replayTurns(g.best, &g.ghostAt, gh)...)
+}
+
+func (g *Game) drawGhost(canvas *ebiten.Image) {
+	gh := g.ghost
+	if gh == nil || gh.Over() || gh.LevelComplete() {
+		return
+	}
+	g.w.drawSnake(canvas, gh.Snake(), g.w.ghostSkin, g.progress(gh))
+}
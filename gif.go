@@ -0,0 +1,132 @@
+package snake
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+const (
+	// gifEvery is the number of updates between two captured frames,
+	// 20 frames per second at 60 updates per second
+	gifEvery = 3
+	// gifFrames is the number of frames kept, the last 10 seconds
+	gifFrames = 200
+	// gifScale is the number of screen pixels per GIF pixel along each
+	// axis, keeping the frames in memory small
+	gifScale = 2
+)
+
+// gifRecorder captures the screen into a ring buffer of frames, so a
+// recording holds the last gifFrames frames however long it ran.
+type gifRecorder struct {
+	frames []*image.RGBA
+	// next is the index of the frame captured next once the buffer is
+	// full
+	next int
+	tick int
+}
+
+// capture keeps every gifEvery-th screen passed, overwriting the oldest
+// frame once the buffer is full.
+func (r *gifRecorder) capture(screen image.Image) {
+	r.tick++
+	if r.tick%gifEvery != 0 {
+		return
+	}
+	sb := screen.Bounds()
+	rect := image.Rect(0, 0, sb.Dx()/gifScale, sb.Dy()/gifScale)
+	var img *image.RGBA
+	if len(r.frames) < gifFrames {
+		img = image.NewRGBA(rect)
+		r.frames = append(r.frames, img)
+	} else {
+		if r.frames[r.next].Rect != rect {
+			// the screen was resized
+			r.frames[r.next] = image.NewRGBA(rect)
+		}
+		img = r.frames[r.next]
+		r.next = (r.next + 1) % gifFrames
+	}
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			c := color.RGBAModel.Convert(screen.At(sb.Min.X+x*gifScale, sb.Min.Y+y*gifScale)).(color.RGBA)
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// ordered returns the frames captured, the oldest first.
+func (r *gifRecorder) ordered() []*image.RGBA {
+	frames := make([]*image.RGBA, 0, len(r.frames))
+	frames = append(frames, r.frames[r.next:]...)
+	return append(frames, r.frames[:r.next]...)
+}
+
+// gifName returns the file name of a recording stopped at t.
+func gifName(t time.Time) string {
+	return "snake-" + t.Format("20060102-150405") + ".gif"
+}
+
+// updateGIF starts and stops recording with F10 and captures the screen
+// drawn this update while recording. A stopped recording is encoded in
+// the background, so the game keeps running smoothly, and saved as a
+// GIF in the working directory.
+func (g *Game) updateGIF(screen *ebiten.Image) {
+	select {
+	case msg := <-g.gifSaved:
+		g.showMessage(msg)
+	default:
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF10) {
+		if g.gif == nil {
+			g.gif = &gifRecorder{}
+			g.showMessage("recording, F10 stops")
+			return
+		}
+		frames := g.gif.ordered()
+		g.gif = nil
+		name := gifName(time.Now())
+		g.showMessage("saving " + name)
+		go func() {
+			if err := saveGIF(name, frames); err != nil {
+				g.gifSaved <- fmt.Sprintf("could not save the recording: %v", err)
+				return
+			}
+			g.gifSaved <- "saved " + name
+		}()
+		return
+	}
+	if g.gif != nil {
+		g.gif.capture(screen)
+	}
+}
+
+// saveGIF encodes frames into an animated GIF file at path.
+func saveGIF(path string, frames []*image.RGBA) error {
+	anim := &gif.GIF{}
+	delay := 100 * gifEvery / DefaultTickRate
+	for _, f := range frames {
+		p := image.NewPaletted(f.Rect, palette.Plan9)
+		draw.Draw(p, f.Rect, f, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, p)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(out, anim); err != nil {
+		out.Close()
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return out.Close()
+}
@@ -1,16 +1,29 @@
 module github.com/wongak/snake
 
+go 1.27.1
+
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/go-gl/gl v0.0.0-20180407155706-68e253793080
 	github.com/go-gl/glfw v0.0.0-20180426074136-46a8d530c326
 	github.com/gopherjs/gopherjs v0.0.0-20180628210949-0892b62f0d9f
+	github.com/gopherjs/gopherwasm v0.1.1
 	github.com/gopherjs/webgl v0.0.0-20180508003723-39bd6d41eeb5
+	github.com/gorilla/websocket v1.5.0
 	github.com/hajimehoshi/ebiten v1.7.0
+	github.com/hajimehoshi/oto v0.1.1
 	github.com/kr/pretty v0.1.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/theckman/go-flock v0.4.0
 	golang.org/x/exp v0.0.0-20180625033341-f9fa0fefb1e1
 	golang.org/x/image v0.0.0-20180628062038-cc896f830ced
 	golang.org/x/mobile v0.0.0-20180618222554-6621de06e1e9
 	golang.org/x/sys v0.0.0-20180627142611-7138fd3d9dc8
+	golang.org/x/text v0.3.0
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127
 )
+
+require (
+	github.com/kr/pty v1.1.1 // indirect
+	github.com/kr/text v0.1.0 // indirect
+)
@@ -0,0 +1,178 @@
+// Package gym exposes the rules of package game as an environment for
+// reinforcement learning in the style of OpenAI Gym: Reset starts an
+// episode, Step moves the snake by one cell with the action of the
+// agent and returns the board afterwards, the reward and whether the
+// episode is over.
+package gym
+
+import (
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+// Action is the direction the agent steers the snake in, numbered like
+// the directions of package game. Reversing into the body is ignored
+// and keeps the current direction.
+type Action int
+
+// The actions.
+const (
+	Right Action = iota
+	Down
+	Left
+	Up
+	// Actions is the number of actions.
+	Actions
+)
+
+// The values of the cells of an observation.
+const (
+	Empty byte = iota
+	Obstacle
+	Body
+	Head
+	Food
+	// Hazard is poison or the forbidden food
+	Hazard
+	Powerup
+	RivalBody
+	RivalHead
+	// Values is the number of cell values.
+	Values
+)
+
+// Rewards of a step.
+const (
+	RewardFood   = 1.0
+	RewardPoison = -0.5
+	RewardDeath  = -1.0
+)
+
+// Observation is the board after a step. Cells holds one value per cell
+// row by row, so it can be fed to a network as a Height by Width
+// tensor.
+type Observation struct {
+	Width, Height int
+	Cells         []byte
+}
+
+// At returns the value of the cell (x, y).
+func (o Observation) At(x, y int) byte {
+	return o.Cells[y*o.Width+x]
+}
+
+// Env is an environment playing the rules of package game. The agent
+// steers the snake of player one, a computer snake of the rules is
+// steered by its bot.
+type Env struct {
+	rules game.Rules
+	seed  int64
+	sim   *game.Game
+}
+
+// New creates an environment with the given rules. The autopilot of the
+// rules is ignored, the agent steers instead. Episodes are seeded with
+// seed, seed+1 and so on, with 0 the seeds are taken from the clock.
+// Completed campaign levels are left for the next one within the
+// episode, completing the last one ends it.
+func New(r game.Rules, seed int64) *Env {
+	r.Autopilot = nil
+	e := &Env{rules: r, seed: seed}
+	e.sim = game.New(r, e.nextSeed())
+	return e
+}
+
+func (e *Env) nextSeed() int64 {
+	if e.seed == 0 {
+		return time.Now().UnixNano()
+	}
+	s := e.seed
+	e.seed++
+	return s
+}
+
+// Game returns the running game, for rendering or statistics. It must
+// not be advanced directly.
+func (e *Env) Game() *game.Game {
+	return e.sim
+}
+
+// Reset starts a new episode and returns the first observation.
+func (e *Env) Reset() Observation {
+	if e.sim.Frame() > 0 {
+		e.sim.Reset(e.nextSeed())
+	}
+	return e.observe()
+}
+
+// Step steers the snake in the direction a, advances the game until the
+// snake moved by one cell and returns the observation, the reward of
+// the step and whether the episode is over. Stepping a finished episode
+// returns done without advancing it.
+func (e *Env) Step(a Action) (obs Observation, reward float64, done bool) {
+	sim := e.sim
+	if sim.Over() {
+		return e.observe(), 0, true
+	}
+	if sim.LevelComplete() {
+		sim.NextLevel()
+	}
+	head := sim.Snake().Head()
+	turns := []game.Turn{{Direction: int(a)}}
+	for !sim.Over() && !sim.LevelComplete() && sim.Snake().Head() == head {
+		for _, ev := range sim.Tick(turns...) {
+			switch {
+			case ev.Kind == game.EventEat && ev.Player == 0:
+				reward += RewardFood
+			case ev.Kind == game.EventPoison:
+				reward += RewardPoison
+			}
+		}
+		turns = nil
+	}
+	if sim.Over() {
+		reward += RewardDeath
+	}
+	return e.observe(), reward, sim.Over()
+}
+
+// observe returns the board as an observation.
+func (e *Env) observe() Observation {
+	sim := e.sim
+	cellsX, cellsY := sim.Size()
+	o := Observation{Width: cellsX + 1, Height: cellsY + 1}
+	o.Cells = make([]byte, o.Width*o.Height)
+	set := func(x, y int, v byte) {
+		if x >= 0 && x < o.Width && y >= 0 && y < o.Height {
+			o.Cells[y*o.Width+x] = v
+		}
+	}
+	for _, c := range sim.Obstacles() {
+		set(c.X, c.Y, Obstacle)
+	}
+	for _, f := range sim.Foods() {
+		set(f.X, f.Y, Food)
+	}
+	for _, p := range sim.Poisons() {
+		set(p.X, p.Y, Hazard)
+	}
+	if f := sim.Forbidden(); f != nil {
+		set(f.X, f.Y, Hazard)
+	}
+	if p := sim.Powerup(); p != nil {
+		set(p.X, p.Y, Powerup)
+	}
+	snake := func(s *game.Snake, body, head byte) {
+		s.Each(func(c game.Cell) {
+			set(c.X, c.Y, body)
+		})
+		h := s.Head()
+		set(h.X, h.Y, head)
+	}
+	if r := sim.Rival(); r != nil {
+		snake(r, RivalBody, RivalHead)
+	}
+	snake(sim.Snake(), Body, Head)
+	return o
+}
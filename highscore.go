@@ -0,0 +1,139 @@
+package snake
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxHighScores is the number of entries kept in the high score table.
+const maxHighScores = 10
+
+// HighScore is an entry in the high score table.
+type HighScore struct {
+	Name  string    `json:"name"`
+	Score int64     `json:"score"`
+	Date  time.Time `json:"date"`
+}
+
+// highScores is the high score table, sorted from best to worst.
+type highScores []HighScore
+
+// DefaultHighScoreFile returns the path of the high score table in the
+// user's config directory.
+func DefaultHighScoreFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "highscores.json")
+}
+
+// scoreMode returns the name of the current mode. Daily challenges,
+// wall, tron and campaign games have high scores of their own.
+func (g *Game) scoreMode() string {
+	switch {
+	case g.options.Daily:
+		return "daily-" + dailyDate()
+	case g.options.Campaign:
+		return "campaign"
+	case g.options.Coop:
+		return "coop"
+	case g.options.Shrink:
+		return "shrink"
+	case g.options.Gauntlet > 0:
+		return "gauntlet"
+	case g.options.Tron:
+		return "tron"
+	case g.options.Walls:
+		return "walls"
+	}
+	return "classic"
+}
+
+// scoreFile returns the path of the high score table of the current
+// mode, empty if the table is kept in memory. The tables of the other
+// modes are kept next to the table of the classic game.
+func (g *Game) scoreFile() string {
+	path := g.options.HighScoreFile
+	mode := g.scoreMode()
+	if path == "" || mode == "classic" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + mode + ext
+}
+
+// loadScores loads the high score table of the current mode.
+func (g *Game) loadScores() {
+	g.scores = nil
+	g.best = nil
+	path := g.scoreFile()
+	if path == "" {
+		return
+	}
+	scores, err := loadHighScores(path)
+	if err != nil {
+		log.Printf("could not load high scores: %v", err)
+	}
+	g.scores = scores
+	g.loadBest()
+}
+
+// loadHighScores reads the high score table from path. A missing file
+// is an empty table.
+func loadHighScores(path string) (highScores, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scores highScores
+	if err := json.Unmarshal(b, &scores); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	if len(scores) > maxHighScores {
+		scores = scores[:maxHighScores]
+	}
+	return scores, nil
+}
+
+func (s highScores) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// qualifies reports whether score makes it into the table.
+func (s highScores) qualifies(score int64) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(s) < maxHighScores || score > s[len(s)-1].Score
+}
+
+// insert adds an entry at its rank and drops entries beyond the table
+// size.
+func (s highScores) insert(e HighScore) highScores {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Score < e.Score })
+	s = append(s, HighScore{})
+	copy(s[i+1:], s[i:])
+	s[i] = e
+	if len(s) > maxHighScores {
+		s = s[:maxHighScores]
+	}
+	return s
+}
@@ -0,0 +1,364 @@
+package snake
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/text"
+	"github.com/wongak/snake/game"
+	"golang.org/x/image/font"
+)
+
+// hudFraction is the share of the screen height the HUD text should
+// take up.
+const hudFraction = 0.035
+
+// hudRow returns the y of the baseline of line n of the text shown
+// over the board, counted in lines of the HUD font from the top of the
+// screen.
+func (g *Game) hudRow(n int) int {
+	return n * g.face.Metrics().Height.Ceil()
+}
+
+// drawText draws str with the baseline starting at (x, y). The text is
+// faded by alpha.
+func (g *Game) drawText(canvas *ebiten.Image, str string, x, y int, clr color.Color, alpha float64) {
+	g.drawTextScaled(canvas, str, x, y, clr, alpha, 1)
+}
+
+// drawTextScaled draws text like drawText, scaled by scale.
+func (g *Game) drawTextScaled(canvas *ebiten.Image, str string, x, y int, clr color.Color, alpha, scale float64) {
+	if scale == 1 && alpha >= 1 {
+		text.Draw(canvas, str, g.face, x, y, clr)
+		return
+	}
+	ascent := g.face.Metrics().Ascent.Ceil()
+	tw := font.MeasureString(g.face, str).Ceil()
+	th := g.face.Metrics().Height.Ceil()
+	if g.textImg == nil {
+		g.textImg, _ = ebiten.NewImage(textImgW, th, ebiten.FilterNearest)
+	}
+	if tw > textImgW {
+		tw = textImgW
+	}
+	g.textImg.Clear()
+	text.Draw(g.textImg, str, g.face, 0, ascent, clr)
+	part := &ebiten.DrawImageOptions{}
+	part.SourceRect = &image.Rectangle{Max: image.Point{tw, th}}
+	part.GeoM.Scale(scale, scale)
+	part.GeoM.Translate(float64(x), float64(y)-float64(ascent)*scale)
+	part.ColorM.Scale(1, 1, 1, alpha)
+	canvas.DrawImage(g.textImg, part)
+}
+
+// textImgW is the width of the offscreen image scaled text is drawn to.
+const textImgW = 512
+
+// hudGap is the space between two fields of the HUD in characters.
+const hudGap = 3
+
+// hudField is a value shown on the HUD.
+type hudField struct {
+	text string
+	clr  color.Color
+}
+
+// drawHUD draws the status of the run below the board: the scores, the
+// length of the snake, its speed in steps per second, the level or mode
+// and the time played. Fields continue on the next line if the window
+// is too narrow for them.
+func (g *Game) drawHUD(canvas *ebiten.Image) {
+	w := g.w
+	lh := g.face.Metrics().Height.Ceil()
+	fields := g.hudFields()
+	mx, my := w.margin()
+	x0 := w.cellW + mx
+	gap := font.MeasureString(g.face, strings.Repeat(" ", hudGap)).Ceil()
+	lines := 1
+	x := x0
+	for _, f := range fields {
+		fw := font.MeasureString(g.face, f.text).Ceil()
+		if x > x0 && x+fw > w.screenW-w.cellW {
+			lines++
+			x = x0
+		}
+		x += fw + gap
+	}
+	y := w.cellH*(w.cellsY+6) + my
+	if limit := w.screenH - g.face.Metrics().Descent.Ceil() - (lines-1)*lh; y > limit {
+		// board is larger than the window, keep the HUD visible
+		y = limit
+	}
+	x = x0
+	for _, f := range fields {
+		fw := font.MeasureString(g.face, f.text).Ceil()
+		if x > x0 && x+fw > w.screenW-w.cellW {
+			x = x0
+			y += lh
+		}
+		g.drawText(canvas, f.text, x, y, f.clr, 1)
+		x += fw + gap
+	}
+}
+
+// hudFields returns the fields of the HUD from left to right.
+func (g *Game) hudFields() []hudField {
+	w := g.w
+	var fields []hudField
+	if g.options.TwoPlayer || g.options.AI {
+		rival := "P2 "
+		if g.options.AI {
+			rival = "CPU "
+		}
+		fields = append(fields,
+			hudField{"P1 " + g.formatScore(g.sim.Points()), w.theme.Snake},
+			hudField{rival + g.formatScore(g.sim.RivalPoints()), w.theme.Rival})
+	} else if g.options.Coop {
+		fields = append(fields,
+			hudField{"team " + g.formatScore(g.sim.Points()), w.theme.HUD},
+			hudField{fmt.Sprintf("lives %d", g.sim.Lives()), w.theme.Warning})
+	} else {
+		fields = append(fields, hudField{g.formatScore(g.sim.Points()), w.theme.HUD})
+	}
+	steps := g.clock.rate() / float64(g.sim.StepInterval())
+	length := fmt.Sprintf("length %d", g.sim.Snake().Len())
+	if g.spectating() {
+		one, _ := g.shownSnake(0)
+		two, _ := g.shownSnake(1)
+		length = fmt.Sprintf("lengths %d/%d", one.Len(), two.Len())
+	}
+	fields = append(fields,
+		hudField{length, w.theme.HUD},
+		hudField{fmt.Sprintf("speed %.1f/s", steps), w.theme.HUD},
+		hudField{g.modeName(), w.theme.HUD})
+	if g.options.Campaign {
+		fields = append(fields, hudField{fmt.Sprintf("food %d/%d", g.sim.LevelEaten(), game.LevelFood), w.theme.HUD})
+	}
+	secs := int64(float64(g.sim.Frame()) / g.clock.rate())
+	fields = append(fields, hudField{fmt.Sprintf("time %d:%02d", secs/60, secs%60), w.theme.HUD})
+	return fields
+}
+
+// modeName returns the campaign level, the arena or the mode of the run
+// for the HUD.
+func (g *Game) modeName() string {
+	if g.options.Campaign {
+		str := fmt.Sprintf("level %d/%d", g.sim.Level()+1, len(g.campaign))
+		if l := g.campaign[g.sim.Level()]; l.Name != "" {
+			str += " " + l.Name
+		}
+		return str
+	}
+	var mode string
+	switch {
+	case g.options.Daily:
+		mode = "daily"
+	case g.options.Tron:
+		mode = "tron"
+	case g.options.Walls:
+		mode = "walls"
+	default:
+		mode = "classic"
+	}
+	if g.options.Level != nil && g.options.Level.Name != "" {
+		mode += " " + g.options.Level.Name
+	}
+	return mode
+}
+
+// drawVolume shows the volume levels for a moment after they changed.
+func (g *Game) drawVolume(canvas *ebiten.Image) {
+	if g.volumeShown == 0 {
+		return
+	}
+	w := g.w
+	v := g.sound.Volume()
+	str := fmt.Sprintf("music %d%%  effects %d%%", int(v.Music*100+0.5), int(v.Effects*100+0.5))
+	alpha := math.Min(1, float64(g.volumeShown)/popupLife)
+	g.drawText(canvas, str, w.screenW/2, g.hudRow(3), w.theme.HUD, alpha)
+}
+
+// messageLife is the number of frames a message is shown.
+const messageLife = 90
+
+// showMessage shows a short notice like the confirmation of a save.
+func (g *Game) showMessage(message string) {
+	g.message = message
+	g.messageShown = messageLife
+}
+
+func (g *Game) drawMessage(canvas *ebiten.Image) {
+	if g.messageShown == 0 {
+		return
+	}
+	w := g.w
+	alpha := math.Min(1, float64(g.messageShown)/popupLife)
+	g.drawText(canvas, g.message, w.screenW/2, g.hudRow(4), w.theme.HUD, alpha)
+}
+
+// drawOverlay dims the board and draws lines of text centered
+// vertically. The first line is highlighted.
+func (g *Game) drawOverlay(canvas *ebiten.Image, lines ...string) {
+	w, h := canvas.Size()
+	if g.overlay == nil {
+		g.overlay, _ = ebiten.NewImage(w, h, ebiten.FilterNearest)
+		g.overlay.Fill(g.w.theme.Overlay)
+	}
+	canvas.DrawImage(g.overlay, &ebiten.DrawImageOptions{})
+	lh := g.face.Metrics().Height.Ceil() * 3 / 2
+	x, y := g.w.cellW*2, (h-len(lines)*lh)/2+lh
+	for i, line := range lines {
+		clr := g.w.theme.HUD
+		if i == 0 {
+			clr = g.w.theme.Warning
+		}
+		g.drawText(canvas, line, x, y+i*lh, clr, 1)
+	}
+}
+
+func (g *Game) drawMenu(canvas *ebiten.Image) {
+	mode := "wrap around the edges"
+	if g.options.Walls {
+		mode = "solid walls"
+	}
+	if g.options.Tron {
+		mode += ", tron"
+	}
+	if g.options.Shrink {
+		mode += ", shrinking arena"
+	}
+	if g.options.Gauntlet > 0 {
+		mode += ", gauntlet"
+	}
+	if g.options.Daily {
+		mode += ", daily challenge " + dailyDate()
+	}
+	players := "1 player"
+	switch {
+	case g.options.TwoPlayer:
+		players = "2 players, arrows and WASD"
+	case g.options.Coop:
+		players = "2 players together, arrows and WASD"
+	case g.options.AI:
+		players = "1 player against the computer"
+	}
+	lines := []string{"SNAKE", "press Enter to start"}
+	if g.saved != nil {
+		lines = append(lines, "press "+g.options.Keys.keyNames(ActionLoad)+" to continue the saved game")
+	}
+	lines = append(lines,
+		"mode: "+mode+" (Tab walls, T tron, B shrinking, G gauntlet, D daily)",
+		players+" (2 changes)",
+		g.streakLine(),
+		"colors: "+g.themeName()+" (L changes), shapes: "+onOff(g.options.Shapes)+" (H)",
+		"P pauses, M mutes, +/- volume, C controls, S settings, I statistics, A achievements, Esc quits")
+	g.drawOverlay(canvas, lines...)
+}
+
+// themeName returns the name of the theme shown on the menu.
+func (g *Game) themeName() string {
+	if g.options.Theme.Name == "" {
+		return "custom"
+	}
+	return g.options.Theme.Name
+}
+
+// restartLine returns the line of the game over screen telling how to
+// restart, counting down the seconds until the game restarts on its own
+// with Options.AutoRestart.
+func (g *Game) restartLine() string {
+	keys := g.options.Keys
+	if left := g.autoRestartIn(); left >= 0 {
+		secs := (left + DefaultTickRate - 1) / DefaultTickRate
+		return fmt.Sprintf("restarting in %d - press %s for the menu", secs, keys.keyNames(ActionQuit))
+	}
+	return fmt.Sprintf("press %s to restart / %s for the menu", keys.keyNames(ActionRestart), keys.keyNames(ActionQuit))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func (g *Game) drawGameOver(canvas *ebiten.Image) {
+	if g.options.Connect != "" {
+		g.drawResults(canvas)
+		return
+	}
+	if g.options.TwoPlayer {
+		title := "Draw"
+		if winner := g.sim.Winner(); winner != 0 {
+			title = fmt.Sprintf("Player %d wins", winner)
+		}
+		g.drawOverlay(canvas, title,
+			fmt.Sprintf("player one %d - player two %d", g.sim.Points(), g.sim.RivalPoints()),
+			g.restartLine())
+		return
+	}
+	title := "Game Over"
+	if g.sim.Won() {
+		title = "Campaign complete"
+	}
+	lines := []string{
+		title + " - score " + g.formatScore(g.sim.Points()),
+		g.restartLine(),
+	}
+	global, replaced := g.globalLines()
+	lines = append(lines, global...)
+	if replaced {
+		g.drawOverlay(canvas, lines...)
+		return
+	}
+	for i, e := range g.scores {
+		lines = append(lines, fmt.Sprintf("%2d. %-3s %8s  %s", i+1, e.Name, g.formatScore(e.Score), e.Date.Format("2006-01-02")))
+	}
+	g.drawOverlay(canvas, lines...)
+}
+
+func (g *Game) drawLevelComplete(canvas *ebiten.Image) {
+	g.drawOverlay(canvas,
+		fmt.Sprintf("Level %d complete", g.sim.Level()+1),
+		"score "+g.formatScore(g.sim.Points()),
+		fmt.Sprintf("next: level %d of %d, a little faster", g.sim.Level()+2, len(g.campaign)),
+		"press Enter to continue / Esc for the menu",
+	)
+}
+
+func (g *Game) drawNameEntry(canvas *ebiten.Image) {
+	name := g.name
+	if len(name) < maxNameLen {
+		name += "_"
+	}
+	title := "New high score " + g.formatScore(g.sim.Points()) + "!"
+	if !g.scores.qualifies(g.sim.Points()) {
+		// asked for the online leaderboard only
+		title = "Score " + g.formatScore(g.sim.Points())
+	}
+	g.drawOverlay(canvas,
+		title,
+		"enter your initials: "+name,
+		"press Enter to confirm",
+	)
+}
+
+func (g *Game) drawControls(canvas *ebiten.Image) {
+	lines := []string{"Controls"}
+	for _, a := range Actions() {
+		keys := g.options.Keys.keyNames(a)
+		if a == g.controls {
+			if g.rebinding {
+				keys = "press a key"
+			}
+			keys = "> " + keys + " <"
+		}
+		lines = append(lines, a.String()+": "+keys)
+	}
+	lines = append(lines, "Enter changes, Backspace resets, Esc returns")
+	g.drawOverlay(canvas, lines...)
+}
@@ -0,0 +1,58 @@
+// Package input defines the actions a player triggers, shared by the
+// frontends. Every frontend maps its own keys or buttons to them.
+package input
+
+// Action is something the player triggers with a key.
+type Action int
+
+const (
+	// Up, Down, Left and Right steer the snake.
+	Up Action = iota
+	Down
+	Left
+	Right
+	// Pause pauses and resumes the game.
+	Pause
+	// Restart starts a new game and confirms menus.
+	Restart
+	// Quit leaves the current screen.
+	Quit
+	// Save saves the running game.
+	Save
+	// Load restores the saved game.
+	Load
+)
+
+var names = []string{"up", "down", "left", "right", "pause", "restart", "quit", "save", "load"}
+
+// Actions returns all actions.
+func Actions() []Action {
+	actions := make([]Action, len(names))
+	for i := range actions {
+		actions[i] = Action(i)
+	}
+	return actions
+}
+
+func (a Action) String() string {
+	if a < 0 || int(a) >= len(names) {
+		return "unknown"
+	}
+	return names[a]
+}
+
+// Direction returns the direction a steering action turns the snake
+// to, numbered like the directions of package game.
+func (a Action) Direction() (int, bool) {
+	switch a {
+	case Right:
+		return 0, true
+	case Down:
+		return 1, true
+	case Left:
+		return 2, true
+	case Up:
+		return 3, true
+	}
+	return 0, false
+}
@@ -0,0 +1,75 @@
+package snake
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// The lengths of the effects of a crash in frames.
+const (
+	crashFrames = 60
+	shakeFrames = 20
+	flashFrames = 12
+)
+
+// shakeAmplitude is the largest offset of the board while it shakes as
+// a fraction of the cell size.
+const shakeAmplitude = 0.6
+
+// flashColor is the color the screen flashes in on a crash.
+var flashColor = color.RGBA{0xff, 0x20, 0x20, 0xff}
+
+// crash starts the effects of a crash: the board shakes, the screen
+// flashes and the scattered snake flies apart in slow motion for a
+// moment before the run ends.
+func (g *Game) crash() {
+	g.scatterCrashed()
+	g.state = StateCrashed
+	g.crashLeft = crashFrames
+	g.shake = shakeFrames
+	g.flash = flashFrames
+}
+
+// updateCrash counts down the effects of a crash and ends the run once
+// they are over, or right away if skip is set.
+func (g *Game) updateCrash(skip bool) {
+	g.crashLeft--
+	if g.crashLeft > 0 && !skip {
+		return
+	}
+	g.shake, g.flash = 0, 0
+	g.endRun()
+}
+
+// slowMotion reports whether the particles move at half speed this
+// frame.
+func (g *Game) slowMotion() bool {
+	return g.state == StateCrashed && g.crashLeft%2 == 1
+}
+
+// shakeOffset returns the offset of the board while it shakes, fading
+// out with the shake.
+func (g *Game) shakeOffset() (float64, float64) {
+	if g.shake <= 0 {
+		return 0, 0
+	}
+	a := shakeAmplitude * float64(g.w.cellW) * float64(g.shake) / shakeFrames
+	return (2*rand.Float64() - 1) * a, (2*rand.Float64() - 1) * a
+}
+
+// drawFlash tints the screen in the flash color, fading out.
+func (g *Game) drawFlash(canvas *ebiten.Image) {
+	if g.flash <= 0 {
+		return
+	}
+	if g.flashImg == nil {
+		w, h := canvas.Size()
+		g.flashImg, _ = ebiten.NewImage(w, h, ebiten.FilterNearest)
+		g.flashImg.Fill(flashColor)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, 0.5*float64(g.flash)/flashFrames)
+	canvas.DrawImage(g.flashImg, op)
+}
@@ -0,0 +1,106 @@
+package snake
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/input"
+)
+
+// Action is something the player triggers with a key. The actions are
+// shared with the other frontends through package input.
+type Action = input.Action
+
+// The actions of package input.
+const (
+	// ActionUp, ActionDown, ActionLeft and ActionRight steer the snake.
+	ActionUp    = input.Up
+	ActionDown  = input.Down
+	ActionLeft  = input.Left
+	ActionRight = input.Right
+	// ActionPause pauses and resumes the game.
+	ActionPause = input.Pause
+	// ActionRestart starts a new game and confirms menus.
+	ActionRestart = input.Restart
+	// ActionQuit leaves the current screen.
+	ActionQuit = input.Quit
+	// ActionSave saves the running game.
+	ActionSave = input.Save
+	// ActionLoad restores the saved game.
+	ActionLoad = input.Load
+)
+
+// Actions returns all actions.
+func Actions() []Action {
+	return input.Actions()
+}
+
+// Keymap maps actions to the keys triggering them.
+type Keymap map[Action][]ebiten.Key
+
+// DefaultKeymap returns the keys of the classic game, arrows and WASD
+// to steer.
+func DefaultKeymap() Keymap {
+	return Keymap{
+		ActionUp:      {ebiten.KeyUp, ebiten.KeyW},
+		ActionDown:    {ebiten.KeyDown, ebiten.KeyS},
+		ActionLeft:    {ebiten.KeyLeft, ebiten.KeyA},
+		ActionRight:   {ebiten.KeyRight, ebiten.KeyD},
+		ActionPause:   {ebiten.KeyP},
+		ActionRestart: {ebiten.KeyEnter},
+		ActionQuit:    {ebiten.KeyEscape},
+		ActionSave:    {ebiten.KeyF5},
+		ActionLoad:    {ebiten.KeyF9},
+	}
+}
+
+// ParseKey returns the key named name, ignoring case. Names are the
+// ones of ebiten.Key.String.
+func ParseKey(name string) (ebiten.Key, bool) {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if strings.EqualFold(k.String(), name) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// pressed reports whether any key of a is held down.
+func (m Keymap) pressed(a Action) bool {
+	for _, k := range m[a] {
+		if ebiten.IsKeyPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressed reports whether any key of a was pressed in this frame.
+func (m Keymap) justPressed(a Action) bool {
+	for _, k := range m[a] {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyNames returns the names of the keys of a, separated by commas.
+func (m Keymap) keyNames(a Action) string {
+	names := make([]string, len(m[a]))
+	for i, k := range m[a] {
+		names[i] = k.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// justPressedKey returns a key pressed in this frame.
+func justPressedKey() (ebiten.Key, bool) {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if inpututil.IsKeyJustPressed(k) {
+			return k, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,104 @@
+// Package leaderboard talks to an online leaderboard over HTTP.
+//
+// Scores are posted as a JSON Entry with the Run they were made in to
+// the leaderboard URL, which checks them with Verify. A GET of the same
+// URL with the query parameters mode and limit returns the best entries
+// of the mode as a JSON array, from best to worst.
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Timeout is how long a request to the leaderboard may take.
+const Timeout = 10 * time.Second
+
+// Entry is a score on the leaderboard.
+type Entry struct {
+	Name  string `json:"name"`
+	Score int64  `json:"score"`
+	// Mode is the game mode the score was made in, like "classic" or
+	// "walls". Every mode has a board of its own.
+	Mode string `json:"mode"`
+	// Date is set by the leaderboard when the score is posted.
+	Date time.Time `json:"date,omitempty"`
+	// Run is the run the score was made in, posted with the score only.
+	Run *Run `json:"run,omitempty"`
+}
+
+// ErrRejected is returned by Submit if the leaderboard did not accept
+// the score.
+var ErrRejected = errors.New("leaderboard: score rejected")
+
+// Client posts and fetches the scores of a leaderboard.
+type Client struct {
+	// URL is the endpoint of the leaderboard.
+	URL  string
+	http *http.Client
+}
+
+// NewClient returns a client of the leaderboard at url.
+func NewClient(url string) *Client {
+	return &Client{URL: url, http: &http.Client{Timeout: Timeout}}
+}
+
+// Submit posts the score e.
+func (c *Client) Submit(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(c.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("%w: %s", ErrRejected, bytes.TrimSpace(msg))
+	}
+	return checkStatus(resp)
+}
+
+// Top fetches the best n entries of mode.
+func (c *Client) Top(mode string, n int) ([]Entry, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("mode", mode)
+	q.Set("limit", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	resp, err := c.http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("leaderboard: %v", err)
+	}
+	return entries, nil
+}
+
+// checkStatus returns an error if the leaderboard did not answer with
+// success.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("leaderboard: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package leaderboard
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+const (
+	// MaxFrames is the longest run verified, in ticks: three hours at
+	// 60 ticks per second.
+	MaxFrames = 3 * 60 * 60 * 60
+	// MaxVerifyTime is how long re-simulating a run may take before it
+	// is turned away.
+	MaxVerifyTime = 5 * time.Second
+)
+
+// standardRules are the rules of the classic mode. Every mode plays
+// them with its own changes, see ModeRules.
+var standardRules = game.Rules{
+	CellsX:        60,
+	CellsY:        40,
+	InitialLength: 3,
+	Speed:         12,
+	SpeedUp:       10000,
+}
+
+// Run is the recording of the run a score was made in: the rules and the
+// seed it started with and the turns of the player. As the game is
+// deterministic given these, the leaderboard re-simulates the run to
+// verify the score.
+type Run struct {
+	// Rules are the rules of the run. They have to be the rules of the
+	// mode the score is posted in, see ModeRules.
+	Rules game.Rules `json:"rules"`
+	Seed  int64      `json:"seed"`
+	Turns []Turn     `json:"turns"`
+}
+
+// Turn is a turn of the player's snake accepted on frame Frame.
+type Turn struct {
+	Frame     int64 `json:"f"`
+	Direction int   `json:"d"`
+}
+
+// ModeRules returns the rules all runs posted in mode are played with,
+// so the scores of a board were all made under the same rules. Daily
+// challenges play the classic rules.
+func ModeRules(mode string) (game.Rules, error) {
+	r := standardRules
+	if strings.HasPrefix(mode, "daily-") {
+		return r, nil
+	}
+	switch mode {
+	case "classic":
+	case "walls":
+		r.Walls = true
+	case "tron":
+		r.Tron = true
+	case "shrink":
+		r.Shrink = true
+	case "gauntlet":
+		r.Gauntlet = game.DefaultGauntlet
+	case "campaign":
+		levels, err := game.CampaignLevels()
+		if err != nil {
+			return r, err
+		}
+		r.Campaign = levels
+	default:
+		return r, fmt.Errorf("scores of mode %q are not verified", mode)
+	}
+	return r, nil
+}
+
+// CheckRules reports whether rules are the rules of mode. The bots and
+// the levels are not compared, runs steered by a bot or on a level of
+// their own are never in a mode.
+func CheckRules(mode string, rules game.Rules) error {
+	want, err := ModeRules(mode)
+	if err != nil {
+		return err
+	}
+	if rules.AI || rules.Autopilot != nil || (rules.Level != nil && len(rules.Campaign) == 0) {
+		return fmt.Errorf("the run was not played with the rules of mode %s", mode)
+	}
+	if len(rules.Campaign) > 0 != (len(want.Campaign) > 0) {
+		return fmt.Errorf("the run was not played with the rules of mode %s", mode)
+	}
+	rules.Campaign, rules.Level, rules.Bot, rules.Autopilot = nil, nil, nil, nil
+	want.Campaign = nil
+	if !reflect.DeepEqual(rules, want) {
+		return fmt.Errorf("the run was not played with the rules of mode %s", mode)
+	}
+	return nil
+}
+
+// Verify re-simulates the run of e and reports whether it ended with the
+// score of e, in the mode of e. Only the seed and the turns of the run
+// are taken, the run is played with the rules of the mode.
+func Verify(e Entry) error {
+	r := e.Run
+	if r == nil {
+		return fmt.Errorf("no run to verify the score")
+	}
+	if err := CheckRules(e.Mode, r.Rules); err != nil {
+		return err
+	}
+	if err := r.checkSeed(e.Mode); err != nil {
+		return err
+	}
+	if len(r.Turns) > MaxFrames {
+		return fmt.Errorf("the run has more turns than ticks")
+	}
+	rules, err := ModeRules(e.Mode)
+	if err != nil {
+		return err
+	}
+	sim := game.New(rules, r.Seed)
+	deadline := time.Now().Add(MaxVerifyTime)
+	at := 0
+	for !sim.Over() {
+		if sim.Frame() >= MaxFrames {
+			return fmt.Errorf("the run is longer than %d ticks", MaxFrames)
+		}
+		if sim.Frame()%1024 == 0 && time.Now().After(deadline) {
+			return fmt.Errorf("the run took longer than %v to verify", MaxVerifyTime)
+		}
+		if sim.LevelComplete() {
+			sim.NextLevel()
+			continue
+		}
+		var turns []game.Turn
+		for ; at < len(r.Turns) && r.Turns[at].Frame <= sim.Frame()+1; at++ {
+			turns = append(turns, game.Turn{Direction: r.Turns[at].Direction})
+		}
+		sim.Tick(turns...)
+	}
+	if got := sim.Points(); got != e.Score {
+		return fmt.Errorf("the run scored %d, not %d", got, e.Score)
+	}
+	return nil
+}
+
+// checkSeed reports whether the run of a daily challenge was played
+// with the seed of its date.
+func (r *Run) checkSeed(mode string) error {
+	if date := strings.TrimPrefix(mode, "daily-"); date != mode && r.Seed != game.DailySeed(date) {
+		return fmt.Errorf("the run is not the daily challenge of %s", date)
+	}
+	return nil
+}
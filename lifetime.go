@@ -0,0 +1,165 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// DefaultLifetimeFile returns the path of the lifetime statistics in
+// the user's config directory.
+func DefaultLifetimeFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "stats.json")
+}
+
+// DefaultStreakScore is the score a run needs to count towards the
+// streak unless Options.StreakScore is set.
+const DefaultStreakScore = 500
+
+// Lifetime are the statistics of all runs played by the player.
+type Lifetime struct {
+	Games int   `json:"games"`
+	Eaten int   `json:"food_eaten"`
+	Score int64 `json:"total_score"`
+	// Deaths counts the runs ended by every cause, keyed by its name.
+	Deaths          map[string]int `json:"deaths"`
+	LongestSnake    int            `json:"longest_snake"`
+	LongestSurvival time.Duration  `json:"longest_survival"`
+	// Streak is the number of the last runs in a row scoring at least
+	// the streak score, BestStreak the longest streak so far.
+	Streak     int `json:"streak"`
+	BestStreak int `json:"best_streak"`
+}
+
+// add counts the run of stats which ended by cause. Runs scoring
+// streakScore or more extend the streak, others end it.
+func (l *Lifetime) add(stats Stats, cause game.Cause, streakScore int64) {
+	l.Games++
+	if stats.Score >= streakScore {
+		l.Streak++
+	} else {
+		l.Streak = 0
+	}
+	if l.Streak > l.BestStreak {
+		l.BestStreak = l.Streak
+	}
+	l.Eaten += stats.Eaten
+	l.Score += stats.Score
+	if cause != game.CauseNone {
+		if l.Deaths == nil {
+			l.Deaths = make(map[string]int)
+		}
+		l.Deaths[cause.String()]++
+	}
+	if stats.MaxLength > l.LongestSnake {
+		l.LongestSnake = stats.MaxLength
+	}
+	if stats.Duration > l.LongestSurvival {
+		l.LongestSurvival = stats.Duration
+	}
+}
+
+// loadLifetime reads the lifetime statistics from path. A missing file
+// is a player who has not played yet.
+func loadLifetime(path string) (Lifetime, error) {
+	var l Lifetime
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	if err := json.Unmarshal(b, &l); err != nil {
+		return Lifetime{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return l, nil
+}
+
+// save writes the lifetime statistics to path.
+func (l Lifetime) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// recordLifetime adds the run which just ended to the lifetime
+// statistics. Runs on autopilot and replays are not the player's and
+// are left out.
+func (g *Game) recordLifetime() {
+	if g.demo || g.replay != nil || g.options.Autopilot != nil {
+		return
+	}
+	g.lifetime.add(g.Stats(), g.sim.Cause(), g.streakScore())
+	if g.options.LifetimeFile == "" {
+		return
+	}
+	if err := g.lifetime.save(g.options.LifetimeFile); err != nil {
+		log.Printf("could not save statistics: %v", err)
+	}
+}
+
+// streakScore returns the score a run needs to extend the streak.
+func (g *Game) streakScore() int64 {
+	if g.options.StreakScore > 0 {
+		return g.options.StreakScore
+	}
+	return DefaultStreakScore
+}
+
+// streakLine returns the line of the menu showing the streak. Before
+// the first run it tells how to start one.
+func (g *Game) streakLine() string {
+	l := g.lifetime
+	if l.Streak == 0 && l.BestStreak == 0 {
+		return fmt.Sprintf("score %d or more for a streak", g.streakScore())
+	}
+	return fmt.Sprintf("streak: %d in a row with %d or more, best %d", l.Streak, g.streakScore(), l.BestStreak)
+}
+
+// updateStatistics leaves the statistics screen on Escape or Enter.
+func (g *Game) updateStatistics(back, enter bool) {
+	if back || enter {
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) drawStatistics(canvas *ebiten.Image) {
+	l := g.lifetime
+	lines := []string{
+		"Statistics",
+		fmt.Sprintf("games played: %d", l.Games),
+		fmt.Sprintf("food eaten: %d", l.Eaten),
+		fmt.Sprintf("total score: %d", l.Score),
+		fmt.Sprintf("longest snake: %d", l.LongestSnake),
+		fmt.Sprintf("longest survival: %s", l.LongestSurvival.Round(time.Second)),
+		fmt.Sprintf("streak: %d, best %d", l.Streak, l.BestStreak),
+	}
+	deaths := "deaths:"
+	for c := game.CauseNone + 1; c < game.Causes; c++ {
+		if n := l.Deaths[c.String()]; n > 0 {
+			deaths += fmt.Sprintf(" %s %d", c, n)
+		}
+	}
+	if deaths == "deaths:" {
+		deaths += " none"
+	}
+	lines = append(lines, deaths, "press Enter or Esc to return")
+	g.drawOverlay(canvas, lines...)
+}
@@ -0,0 +1,95 @@
+package snake
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+const (
+	// minimapFraction is the share of the screen width the minimap
+	// takes at most, minimapHeight the share of the screen height.
+	minimapFraction = 0.2
+	minimapHeight   = 0.3
+)
+
+var minimapBg = color.RGBA{0x00, 0x00, 0x00, 0xa0}
+
+// minimap shows the whole board with one pixel per cell, scaled into
+// the top right corner of the screen. On boards larger than the screen
+// a frame marks the part on the screen.
+type minimap struct {
+	img *ebiten.Image
+	// pix are the pixels of img, four bytes per cell
+	pix   []byte
+	w, h  int
+	scale float64
+	opts  ebiten.DrawImageOptions
+}
+
+func newMinimap(w *world) *minimap {
+	m := &minimap{w: w.cellsX + 1, h: w.cellsY + 1}
+	m.scale = math.Min(float64(w.screenW)*minimapFraction/float64(m.w), float64(w.screenH)*minimapHeight/float64(m.h))
+	m.img, _ = ebiten.NewImage(m.w, m.h, ebiten.FilterNearest)
+	m.pix = make([]byte, 4*m.w*m.h)
+	return m
+}
+
+func (m *minimap) dot(x, y int, c color.RGBA) {
+	if x < 0 || y < 0 || x >= m.w || y >= m.h {
+		return
+	}
+	i := 4 * (y*m.w + x)
+	m.pix[i], m.pix[i+1], m.pix[i+2], m.pix[i+3] = c.R, c.G, c.B, c.A
+}
+
+// frame marks the cells from (x0, y0) to (x1, y1) with a frame.
+func (m *minimap) frame(x0, y0, x1, y1 int, c color.RGBA) {
+	for x := x0; x <= x1; x++ {
+		m.dot(x, y0, c)
+		m.dot(x, y1, c)
+	}
+	for y := y0; y <= y1; y++ {
+		m.dot(x0, y, c)
+		m.dot(x1, y, c)
+	}
+}
+
+func (g *Game) drawMinimap(canvas *ebiten.Image) {
+	m, w := g.minimap, g.w
+	for i := 0; i < len(m.pix); i += 4 {
+		m.pix[i], m.pix[i+1], m.pix[i+2], m.pix[i+3] = minimapBg.R, minimapBg.G, minimapBg.B, minimapBg.A
+	}
+	for _, c := range g.sim.Obstacles() {
+		m.dot(c.X, c.Y, w.theme.Obstacle)
+	}
+	for _, f := range g.sim.Foods() {
+		m.dot(f.X, f.Y, w.foodColor(f.Kind))
+	}
+	for _, p := range g.sim.Poisons() {
+		m.dot(p.X, p.Y, w.foodColor(p.Kind))
+	}
+	if f := g.sim.Forbidden(); f != nil {
+		m.dot(f.X, f.Y, w.theme.Forbidden)
+	}
+	for player, clr := range []color.RGBA{w.theme.Snake, w.theme.Rival} {
+		if s, _ := g.shownSnake(player); s != nil {
+			s.Each(func(c game.Cell) {
+				m.dot(c.X, c.Y, clr)
+			})
+		}
+	}
+	if w.scrolls() {
+		// cell x is drawn at pixel (x+1)*cellW of the board
+		x0, y0 := int(w.camX)/w.cellW-1, int(w.camY)/w.cellH-1
+		x1, y1 := int(w.camX+float64(w.screenW))/w.cellW-1, int(w.camY+float64(w.screenH))/w.cellH-1
+		m.frame(x0, y0, x1, y1, w.theme.HUD)
+	}
+	m.img.ReplacePixels(m.pix)
+	m.opts.GeoM.Reset()
+	m.opts.GeoM.Scale(m.scale, m.scale)
+	m.opts.GeoM.Translate(float64(w.screenW)-float64(m.w)*m.scale-float64(w.cellW), float64(w.cellH))
+	canvas.DrawImage(m.img, &m.opts)
+}
@@ -0,0 +1,453 @@
+package snake
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/game"
+	"github.com/wongak/snake/netplay"
+)
+
+// The entries of the room settings in the lobby.
+const (
+	roomArena = iota
+	roomSpeed
+	roomWin
+	roomTarget
+)
+
+// dialed is the outcome of connecting to a netplay server.
+type dialed struct {
+	c   *netplay.Client
+	err error
+}
+
+// restart starts a new run, or opens the lobby when playing online.
+func (g *Game) restart() {
+	if g.options.Connect != "" {
+		g.openLobby()
+		return
+	}
+	g.Reset()
+}
+
+// openLobby shows the lobby, connecting to the server if not connected.
+func (g *Game) openLobby() {
+	g.state = StateLobby
+	if g.net == nil && g.netDialed == nil {
+		g.connect()
+	}
+}
+
+// connect connects to the netplay server in the background.
+func (g *Game) connect() {
+	g.disconnect()
+	g.netStatus = "connecting to " + g.options.Connect
+	url := g.options.Connect
+	done := make(chan dialed, 1)
+	g.netDialed = done
+	go func() {
+		c, err := netplay.Dial(url)
+		done <- dialed{c, err}
+	}()
+}
+
+// disconnect leaves the server, ending a match in progress.
+func (g *Game) disconnect() {
+	if g.net != nil {
+		g.net.Close()
+	}
+	if done := g.netDialed; done != nil {
+		// close the connection once made
+		go func() {
+			if d := <-done; d.c != nil {
+				d.c.Close()
+			}
+		}()
+	}
+	g.net, g.netDialed, g.room = nil, nil, nil
+}
+
+// send sends m to the server. A lost connection is noticed when the
+// messages end, so errors are only logged.
+func (g *Game) send(m netplay.Message) {
+	if g.net == nil {
+		return
+	}
+	if err := g.net.Send(m); err != nil {
+		log.Printf("could not send to the server: %v", err)
+	}
+}
+
+// leaveRoom leaves the room for the lobby, giving up a match in
+// progress.
+func (g *Game) leaveRoom() {
+	g.send(netplay.Message{Type: netplay.MsgLeave})
+	g.room = nil
+	g.netStatus = ""
+	g.chatOpen, g.chatLog = false, nil
+	g.state = StateLobby
+}
+
+// updateNet takes the connection once it is made and the messages of
+// the server received since the last update.
+func (g *Game) updateNet() {
+	select {
+	case d := <-g.netDialed:
+		g.netDialed = nil
+		if d.err != nil {
+			g.netStatus = fmt.Sprintf("could not connect: %v", d.err)
+			return
+		}
+		g.net = d.c
+		g.netStatus = ""
+	default:
+	}
+	for g.net != nil {
+		select {
+		case m, ok := <-g.net.Messages():
+			if !ok {
+				g.lostConnection()
+				return
+			}
+			g.receive(m)
+		default:
+			return
+		}
+	}
+}
+
+// receive handles a message of the server.
+func (g *Game) receive(m netplay.Message) {
+	switch m.Type {
+	case netplay.MsgRoom:
+		g.room, g.netPlayer = m.Room, m.Player
+		if m.Spectating {
+			g.netPlayer = -1
+		}
+		if g.state == StateGameOver && m.Room != nil && !m.Room.Results {
+			// a player left
+			g.netStatus = "your opponent left the room"
+			if g.netPlayer < 0 {
+				g.netStatus = "a player left the room"
+			}
+			g.state = StateLobby
+		}
+	case netplay.MsgError:
+		g.netStatus = m.Error
+		if g.state == StatePlaying {
+			g.showMessage(m.Error)
+		}
+	case netplay.MsgChat, netplay.MsgEmote:
+		g.receiveChat(m)
+	case netplay.MsgStart:
+		if m.Rules == nil {
+			break
+		}
+		if m.Spectating {
+			g.startMatch(*m.Rules, -1)
+		} else {
+			g.startMatch(*m.Rules, m.Player)
+		}
+	case netplay.MsgSnapshot:
+		if m.Snapshot != nil && g.state == StatePlaying {
+			g.pred.receive(m.Snapshot, m.Seq)
+		}
+	case netplay.MsgOver:
+		g.endMatch(m.Winner, m.Left)
+	}
+}
+
+// startMatch starts a match on the arena of rules, with the local
+// player as player, or -1 to watch it.
+func (g *Game) startMatch(rules game.Rules, player int) {
+	o := &g.options
+	o.CellsX, o.CellsY = rules.CellsX, rules.CellsY
+	o.Walls, o.TwoPlayer, o.AI, o.Tron = rules.Walls, true, false, rules.Tron
+	o.Coop, o.Shrink, o.Gauntlet = false, rules.Shrink, rules.Gauntlet
+	g.sim = game.New(rules, 0)
+	g.seed = 0
+	g.ghost = nil
+	g.initWorld()
+	g.anims.clear()
+	g.particles.clear()
+	g.netPlayer, g.netTurn = player, -1
+	g.pred = newPrediction(rules, player < 0)
+	g.state = StatePlaying
+	if player < 0 {
+		// the match may be under way, the arena is shown as it comes
+		g.countdown = 0
+		g.watch(0)
+		return
+	}
+	g.startCountdown()
+	g.showMessage(fmt.Sprintf("you are player %d", player+1))
+}
+
+// endMatch shows the result of the match won by winner, 1 or 2, or
+// drawn with 0. If the opponent left, the room is shown instead.
+func (g *Game) endMatch(winner int, left bool) {
+	switch {
+	case left && g.netPlayer < 0:
+		g.netStatus = fmt.Sprintf("player %d left - player %d wins", 3-winner, winner)
+		g.state = StateLobby
+		return
+	case left:
+		g.netStatus = "your opponent left - you win"
+		g.state = StateLobby
+		return
+	case winner == 0:
+		g.netResult = "Draw"
+	case g.netPlayer < 0:
+		g.netResult = fmt.Sprintf("player %d wins", winner)
+	case winner == g.netPlayer+1:
+		g.netResult = "You win"
+	default:
+		g.netResult = "You lose"
+	}
+	g.confirm()
+	g.state = StateGameOver
+}
+
+// lostConnection returns to the lobby after the connection to the
+// server was lost.
+func (g *Game) lostConnection() {
+	err := g.net.Err()
+	g.disconnect()
+	g.netStatus = fmt.Sprintf("connection lost: %v", err)
+	if g.state == StatePlaying || g.state == StateGameOver {
+		g.state = StateLobby
+	}
+}
+
+// ownSnake returns the snake of the local player.
+func (g *Game) ownSnake() *game.Snake {
+	if g.net != nil && g.netPlayer == 1 {
+		return g.sim.Rival()
+	}
+	return g.sim.Snake()
+}
+
+// updateLobby handles the lobby. Without a room the code of a room is
+// typed and joined with Enter, Enter without a code creates a room. In
+// a room Enter toggles being ready and player one changes the settings
+// with the arrow keys. Escape leaves the room or the lobby.
+func (g *Game) updateLobby(back, enter bool) {
+	switch {
+	case g.net == nil:
+		if back {
+			g.disconnect()
+			g.state = StateMenu
+		} else if enter && g.netDialed == nil {
+			g.connect()
+		}
+	case g.room == nil:
+		g.updateRoomCode(back, enter)
+	case back:
+		g.leaveRoom()
+	case g.netPlayer < 0:
+		// spectators wait for the match
+	case enter:
+		g.send(netplay.Message{Type: netplay.MsgReady, Ready: !g.room.Ready[g.netPlayer]})
+	case g.netPlayer == 0:
+		g.updateRoomSettings()
+	}
+}
+
+// updateRoomCode reads the code of the room to join.
+func (g *Game) updateRoomCode(back, enter bool) {
+	for _, r := range ebiten.InputChars() {
+		if len(g.roomCode) >= roomCodeLen {
+			break
+		}
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r >= 'A' && r <= 'Z' {
+			g.roomCode += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.roomCode) > 0 {
+		g.roomCode = g.roomCode[:len(g.roomCode)-1]
+	}
+	switch {
+	case back:
+		g.disconnect()
+		g.state = StateMenu
+	case enter && g.roomCode == "":
+		g.send(netplay.Message{Type: netplay.MsgCreate})
+	case enter:
+		g.send(netplay.Message{Type: netplay.MsgJoin, Code: g.roomCode})
+		g.roomCode = ""
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab) && g.roomCode != "":
+		g.send(netplay.Message{Type: netplay.MsgWatch, Code: g.roomCode})
+		g.roomCode = ""
+	}
+}
+
+// roomCodeLen is the number of letters of a room code.
+const roomCodeLen = 4
+
+// roomEntries returns the settings of the room shown in the lobby. The
+// target is only offered for the win conditions having one.
+func (g *Game) roomEntries() []int {
+	entries := []int{roomArena, roomSpeed, roomWin}
+	if win := g.room.Settings.Win; win == netplay.WinPoints || win == netplay.WinTime {
+		entries = append(entries, roomTarget)
+	}
+	return entries
+}
+
+// updateRoomSettings lets player one choose a setting of the room with
+// up and down and change it with left and right.
+func (g *Game) updateRoomSettings() {
+	entries := g.roomEntries()
+	if g.roomEntry >= len(entries) {
+		g.roomEntry = len(entries) - 1
+	}
+	step := 0
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		g.roomEntry = (g.roomEntry + len(entries) - 1) % len(entries)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		g.roomEntry = (g.roomEntry + 1) % len(entries)
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		step = -1
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		step = 1
+	}
+	if step == 0 {
+		return
+	}
+	s := g.room.Settings
+	switch entries[g.roomEntry] {
+	case roomArena:
+		s.Arena = shiftPreset(s.Arena, step, len(netplay.Arenas))
+	case roomSpeed:
+		s.Speed = shiftPreset(s.Speed, step, len(netplay.Speeds))
+	case roomWin:
+		s.Win = shiftPreset(s.Win, step, len(netplay.WinNames))
+	case roomTarget:
+		s.Target = shiftPreset(s.Target, step, len(netplay.TargetPoints))
+	}
+	g.send(netplay.Message{Type: netplay.MsgSettings, Settings: &s})
+}
+
+// roomValue returns the value of the room setting of entry.
+func (g *Game) roomValue(entry int) string {
+	s := g.room.Settings
+	switch entry {
+	case roomArena:
+		a := netplay.Arenas[s.Arena]
+		return fmt.Sprintf("arena: %s, %dx%d", a.Name, a.CellsX, a.CellsY)
+	case roomSpeed:
+		return "speed: " + netplay.Speeds[s.Speed].Name
+	case roomWin:
+		return "win: " + netplay.WinNames[s.Win]
+	case roomTarget:
+		if s.Win == netplay.WinTime {
+			return fmt.Sprintf("time: %d seconds", netplay.TargetSeconds[s.Target])
+		}
+		return fmt.Sprintf("points: %d", netplay.TargetPoints[s.Target])
+	}
+	return ""
+}
+
+// playerState describes whether player i of the room is ready.
+func (g *Game) playerState(i int) string {
+	you := ""
+	if i == g.netPlayer {
+		you = " (you)"
+	}
+	switch {
+	case i >= g.room.Players:
+		return fmt.Sprintf("player %d: waiting to join", i+1)
+	case g.room.Ready[i]:
+		return fmt.Sprintf("player %d%s: ready", i+1, you)
+	}
+	return fmt.Sprintf("player %d%s: not ready", i+1, you)
+}
+
+func (g *Game) drawLobby(canvas *ebiten.Image) {
+	lines := []string{"Online match"}
+	switch {
+	case g.net == nil:
+		lines = append(lines, g.netStatus)
+		if g.netDialed == nil {
+			lines = append(lines, "press Enter to try again / Esc for the menu")
+		} else {
+			lines = append(lines, "press Esc for the menu")
+		}
+	case g.room == nil:
+		code := g.roomCode
+		if len(code) < roomCodeLen {
+			code += "_"
+		}
+		lines = append(lines,
+			"room code: "+code,
+			"type a code and press Enter to join the room",
+			"or Tab to watch it, or press Enter to create a room",
+			"press Esc for the menu")
+		if g.netStatus != "" {
+			lines = append(lines, g.netStatus)
+		}
+	default:
+		lines = append(lines, "room "+g.room.Code+" - tell your opponent the code")
+		for i, entry := range g.roomEntries() {
+			prefix := "  "
+			if g.netPlayer == 0 && i == g.roomEntry {
+				prefix = "> "
+			}
+			lines = append(lines, prefix+g.roomValue(entry))
+		}
+		lines = append(lines, g.playerState(0), g.playerState(1))
+		if n := g.room.Spectators; n > 0 {
+			lines = append(lines, fmt.Sprintf("%d watching", n))
+		}
+		switch g.netPlayer {
+		case -1:
+			lines = append(lines, "you are watching, Esc leaves the room")
+		case 0:
+			lines = append(lines, "Up/Down choose, Left/Right change")
+			fallthrough
+		default:
+			lines = append(lines, "Enter toggles ready, Esc leaves the room")
+		}
+		if g.netStatus != "" {
+			lines = append(lines, g.netStatus)
+		}
+	}
+	g.drawOverlay(canvas, lines...)
+}
+
+// updateResults votes for a rematch on Enter and leaves the room on
+// Escape.
+func (g *Game) updateResults(back, enter bool) {
+	switch {
+	case back:
+		g.leaveRoom()
+	case enter && g.netPlayer >= 0:
+		g.send(netplay.Message{Type: netplay.MsgRematch})
+	}
+}
+
+func (g *Game) drawResults(canvas *ebiten.Image) {
+	lines := []string{g.netResult,
+		fmt.Sprintf("player one %d - player two %d", g.sim.Points(), g.sim.RivalPoints())}
+	r, i := g.room, g.netPlayer
+	switch {
+	case i < 0:
+		lines = append(lines, "waiting for a rematch / press Esc to leave the room")
+		g.drawOverlay(canvas, lines...)
+		return
+	case r == nil, r.Rematch[i] && r.Rematch[1-i]:
+	case r.Rematch[i]:
+		lines = append(lines, "waiting for your opponent to vote for a rematch")
+	case r.Rematch[1-i]:
+		lines = append(lines, "your opponent wants a rematch")
+	}
+	lines = append(lines, "press Enter to vote for a rematch / Esc to leave the room")
+	g.drawOverlay(canvas, lines...)
+}
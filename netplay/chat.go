@@ -0,0 +1,105 @@
+package netplay
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxChat is the longest chat message relayed, in characters.
+const MaxChat = 120
+
+const (
+	// chatBurst is the number of chat messages and emotes a client may
+	// send at once, chatInterval the time until it may send another.
+	chatBurst    = 5
+	chatInterval = 2 * time.Second
+)
+
+// Emotes are the quick reactions sent with MsgEmote.
+var Emotes = []string{"GG", "Nice!", "Oops", "Hurry up!", "Wow", "Rematch?"}
+
+// Filter checks a chat message before the server relays it. It returns
+// the text to relay, with unwanted words masked, or false to drop the
+// message.
+type Filter func(text string) (string, bool)
+
+// WordFilter returns a Filter masking the words in words with
+// asterisks, ignoring case.
+func WordFilter(words []string) Filter {
+	bad := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			bad[w] = true
+		}
+	}
+	return func(text string) (string, bool) {
+		var b strings.Builder
+		word := func(w string) {
+			if bad[strings.ToLower(w)] {
+				w = strings.Repeat("*", utf8.RuneCountInString(w))
+			}
+			b.WriteString(w)
+		}
+		start := -1
+		for i, r := range text {
+			letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+			switch {
+			case letter && start < 0:
+				start = i
+			case !letter && start >= 0:
+				word(text[start:i])
+				start = -1
+				fallthrough
+			case !letter:
+				b.WriteRune(r)
+			}
+		}
+		if start >= 0 {
+			word(text[start:])
+		}
+		return b.String(), true
+	}
+}
+
+// cleanChat returns text without control characters and surrounding
+// space, cut to MaxChat characters.
+func cleanChat(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > MaxChat {
+		text = string([]rune(text)[:MaxChat])
+	}
+	return text
+}
+
+// limiter limits how often a client chats: it allows bursts of
+// chatBurst messages and one more every chatInterval.
+type limiter struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow reports whether a message sent at now is allowed.
+func (l *limiter) allow(now time.Time) bool {
+	if l.last.IsZero() {
+		l.tokens = chatBurst
+	} else {
+		l.tokens += float64(now.Sub(l.last)) / float64(chatInterval)
+		if l.tokens > chatBurst {
+			l.tokens = chatBurst
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,66 @@
+package netplay
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client is the connection of a player to a server.
+type Client struct {
+	conn *websocket.Conn
+	msgs chan Message
+	err  error
+}
+
+// Dial connects to the server at url, like ws://localhost:8080/play.
+func Dial(url string) (*Client, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, msgs: make(chan Message, 64)}
+	go c.read()
+	return c, nil
+}
+
+func (c *Client) read() {
+	defer close(c.msgs)
+	for {
+		var m Message
+		if err := c.conn.ReadJSON(&m); err != nil {
+			c.err = err
+			return
+		}
+		c.msgs <- m
+	}
+}
+
+// Messages returns the messages received from the server. It is closed
+// once the connection is lost.
+func (c *Client) Messages() <-chan Message {
+	return c.msgs
+}
+
+// Err returns the error the connection was lost with, once Messages is
+// closed.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Send sends m to the server.
+func (c *Client) Send(m Message) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteJSON(m)
+}
+
+// Turn turns the snake of the player in direction, as the turn seq of
+// the match.
+func (c *Client) Turn(direction, seq int) error {
+	return c.Send(Message{Type: MsgTurn, Direction: direction, Seq: seq})
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,112 @@
+// Package netplay runs head-to-head games of two players over
+// WebSocket.
+//
+// Players meet in rooms: one creates a room and tells the other its
+// code to join. Once both are ready, the server simulates the arena and
+// is the authority on it: clients send the turns of their player and
+// show the snapshots of the arena the server sends every tick. More
+// clients may join a room as spectators, getting the snapshots too.
+// After a match both players vote for a rematch or leave. Every message
+// is a JSON Message.
+package netplay
+
+import (
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+// The types of the messages sent by clients.
+const (
+	// MsgCreate creates a room with Settings.
+	MsgCreate = "create"
+	// MsgJoin joins the room with Code.
+	MsgJoin = "join"
+	// MsgWatch joins the room with Code as a spectator.
+	MsgWatch = "watch"
+	// MsgLeave leaves the room, giving up a match in progress.
+	MsgLeave = "leave"
+	// MsgReady tells whether the player is Ready for the match.
+	MsgReady = "ready"
+	// MsgSettings changes the Settings of the room. Only the player who
+	// created the room may.
+	MsgSettings = "settings"
+	// MsgRematch votes for another match after a match.
+	MsgRematch = "rematch"
+	// MsgTurn turns the snake of the player in Direction. Seq numbers
+	// the turns of a match from 1.
+	MsgTurn = "turn"
+	// MsgChat says Text in the room, MsgEmote shows one of the Emotes.
+	// The server relays both to everyone in the room, Player being the
+	// player who sent it or -1 for a spectator.
+	MsgChat  = "chat"
+	MsgEmote = "emote"
+)
+
+// The types of the messages sent by the server.
+const (
+	// MsgRoom is the Room the client is in after it changed, nil after
+	// leaving it. Player is the player of the client, unless it is
+	// Spectating.
+	MsgRoom = "room"
+	// MsgError tells the client why its last message was refused.
+	MsgError = "error"
+	// MsgStart starts a match. Player is the player of the client and
+	// Rules are the rules of the arena. The first snapshot follows
+	// after StartDelay. Spectators joining during a match get it
+	// before the next snapshot.
+	MsgStart = "start"
+	// MsgSnapshot is the arena after a tick. Seq is the last turn of the
+	// player applied to it.
+	MsgSnapshot = "snapshot"
+	// MsgOver ends a match, with the Winner.
+	MsgOver = "over"
+)
+
+// StartDelay is the time between the start of a match and its first
+// tick, for the players to get ready.
+const StartDelay = 3 * time.Second
+
+// Message is a message between the server and a client.
+type Message struct {
+	Type string `json:"type"`
+	// Player is 0 for player one and 1 for player two.
+	Player   int            `json:"player,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Settings *Settings      `json:"settings,omitempty"`
+	Room     *Room          `json:"room,omitempty"`
+	Ready    bool           `json:"ready,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Rules    *game.Rules    `json:"rules,omitempty"`
+	Snapshot *game.Snapshot `json:"snapshot,omitempty"`
+	// Direction is 0 right, 1 down, 2 left or 3 up.
+	Direction int `json:"direction,omitempty"`
+	Seq       int `json:"seq,omitempty"`
+	// Winner is 1 or 2 for the player who won, 0 on a draw.
+	Winner int `json:"winner,omitempty"`
+	// Left is set if the match ended because the opponent left.
+	Left bool `json:"left,omitempty"`
+	// Spectating is set for spectators.
+	Spectating bool `json:"spectating,omitempty"`
+	// Text is a chat message and Emote the index of an emote.
+	Text  string `json:"text,omitempty"`
+	Emote int    `json:"emote,omitempty"`
+}
+
+// Room is the state of a room as shown to its players.
+type Room struct {
+	Code     string   `json:"code"`
+	Settings Settings `json:"settings"`
+	// Players is the number of players in the room. The player who
+	// created it is player one, or who stayed if they left.
+	Players int     `json:"players"`
+	Ready   [2]bool `json:"ready"`
+	// Spectators is the number of spectators watching.
+	Spectators int `json:"spectators,omitempty"`
+	// Playing is set during a match. Results is set after a match until
+	// both players voted for a rematch or one of them left.
+	Playing bool `json:"playing,omitempty"`
+	Results bool `json:"results,omitempty"`
+	// Rematch are the votes for another match after a match.
+	Rematch [2]bool `json:"rematch"`
+}
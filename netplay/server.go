@@ -0,0 +1,526 @@
+package netplay
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/wongak/snake/game"
+)
+
+const (
+	// writeTimeout is how long sending a message to a client may take.
+	writeTimeout = 5 * time.Second
+	// readLimit is the size of the largest message accepted from a
+	// client, in bytes.
+	readLimit = 4096
+	// pongTimeout is how long a client may stay silent. The server pings
+	// every pingInterval and every pong extends the deadline.
+	pongTimeout  = 60 * time.Second
+	pingInterval = pongTimeout * 9 / 10
+	// codeLetters are the letters of room codes, without the ones
+	// mistaken for digits.
+	codeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	codeLen     = 4
+	// maxTurns is the number of turns a room takes per tick.
+	maxTurns = 16
+	// maxSpectators is the number of spectators a room takes.
+	maxSpectators = 16
+)
+
+// Server runs the rooms of the clients connecting.
+type Server struct {
+	// Filter checks the chat messages if set. It must be set before
+	// the server is used.
+	Filter Filter
+
+	base     game.Rules
+	defaults Settings
+	tickRate int
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	rooms map[string]*room
+	// rng picks the room codes
+	rng *rand.Rand
+}
+
+// NewServer returns a server running matches with the base rules at
+// tickRate ticks per second. The settings of a room replace the board
+// size and the speed, new rooms have defaults.
+func NewServer(base game.Rules, defaults Settings, tickRate int) *Server {
+	base.TwoPlayer = true
+	base.AI, base.Autopilot = false, nil
+	base.Campaign, base.Level = nil, nil
+	return &Server{
+		base:     base,
+		defaults: defaults,
+		tickRate: tickRate,
+		rooms:    make(map[string]*room),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// ServeHTTP takes a client connecting over WebSocket and serves it until
+// the connection is lost.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade answered the request with the error
+		return
+	}
+	p := &player{conn: conn, in: make(chan Message, 16)}
+	go p.read()
+	s.serve(p)
+}
+
+// serve passes the messages of p to its room, creating or joining one
+// first.
+func (s *Server) serve(p *player) {
+	defer p.conn.Close()
+	var r *room
+	for m := range p.in {
+		switch {
+		case r != nil:
+			if !r.post(p, m) {
+				r = nil
+			}
+		case m.Type == MsgCreate:
+			r = s.create(p, m.Settings)
+		case m.Type == MsgJoin || m.Type == MsgWatch:
+			r = s.join(p, m)
+		}
+	}
+	if r != nil {
+		r.post(p, Message{Type: MsgLeave})
+	}
+}
+
+// create creates a room with settings, the defaults if nil, and lets p
+// join it.
+func (s *Server) create(p *player, settings *Settings) *room {
+	set := s.defaults
+	if settings != nil {
+		if !settings.Valid() {
+			p.send(Message{Type: MsgError, Error: "invalid settings"})
+			return nil
+		}
+		set = *settings
+	}
+	s.mu.Lock()
+	code := s.newCode()
+	r := &room{code: code, s: s, settings: set, msgs: make(chan roomMsg), done: make(chan struct{})}
+	s.rooms[code] = r
+	s.mu.Unlock()
+	go r.run()
+	if !r.post(p, Message{Type: MsgJoin}) {
+		return nil
+	}
+	return r
+}
+
+// newCode returns a code no room has. s.mu is held.
+func (s *Server) newCode() string {
+	for {
+		b := make([]byte, codeLen)
+		for i := range b {
+			b[i] = codeLetters[s.rng.Intn(len(codeLetters))]
+		}
+		if _, ok := s.rooms[string(b)]; !ok {
+			return string(b)
+		}
+	}
+}
+
+// join lets p join the room with the code of m, as a player or, for
+// MsgWatch, as a spectator.
+func (s *Server) join(p *player, m Message) *room {
+	code := strings.ToUpper(strings.TrimSpace(m.Code))
+	s.mu.Lock()
+	r := s.rooms[code]
+	s.mu.Unlock()
+	if r == nil || !r.post(p, Message{Type: m.Type}) {
+		if r == nil || r.closed() {
+			p.send(Message{Type: MsgError, Error: "there is no room " + code})
+		}
+		return nil
+	}
+	return r
+}
+
+// remove forgets the room r once the last client left.
+func (s *Server) remove(r *room) {
+	s.mu.Lock()
+	delete(s.rooms, r.code)
+	s.mu.Unlock()
+}
+
+// roomMsg is a message of a player to its room. in receives whether the
+// player is in the room after it.
+type roomMsg struct {
+	p  *player
+	m  Message
+	in chan bool
+}
+
+// room is a room of up to two players and their spectators. Its state
+// is only touched by run.
+type room struct {
+	code string
+	s    *Server
+	msgs chan roomMsg
+	// done is closed once the room is closed
+	done chan struct{}
+
+	players    []*player
+	spectators []*player
+	settings   Settings
+	ready      [2]bool
+	rematch    [2]bool
+	results    bool
+	// sim is the match played with rules, nil between matches. It
+	// starts ticking once start fires. turns are applied on the next
+	// tick, up to the turn seqs of the players, and acks are the last
+	// turns applied.
+	sim   *game.Game
+	rules game.Rules
+	turns []game.Turn
+	seqs  [2]int
+	acks  [2]int
+	start *time.Timer
+	tick  *time.Ticker
+}
+
+// post passes the message m of p to the room and reports whether p is
+// in the room after it.
+func (r *room) post(p *player, m Message) bool {
+	in := make(chan bool, 1)
+	select {
+	case r.msgs <- roomMsg{p, m, in}:
+		return <-in
+	case <-r.done:
+		return false
+	}
+}
+
+// closed reports whether the room was closed.
+func (r *room) closed() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// run handles the messages of the clients and runs the matches until
+// the last client left.
+func (r *room) run() {
+	defer close(r.done)
+	for {
+		var start, tick <-chan time.Time
+		if r.start != nil {
+			start = r.start.C
+		}
+		if r.tick != nil {
+			tick = r.tick.C
+		}
+		select {
+		case rm := <-r.msgs:
+			rm.in <- r.handle(rm.p, rm.m)
+			if len(r.players) == 0 && len(r.spectators) == 0 {
+				r.s.remove(r)
+				return
+			}
+		case <-start:
+			r.start = nil
+			r.tick = time.NewTicker(time.Second / time.Duration(r.s.tickRate))
+		case <-tick:
+			r.advance()
+		}
+	}
+}
+
+// handle handles the message m of p and reports whether p is in the
+// room after it.
+func (r *room) handle(p *player, m Message) bool {
+	if s := find(r.spectators, p); s >= 0 {
+		// spectators can only chat and leave
+		switch m.Type {
+		case MsgChat, MsgEmote:
+			r.chat(p, -1, m)
+		case MsgLeave:
+			r.spectators = append(r.spectators[:s], r.spectators[s+1:]...)
+			r.broadcast()
+			return false
+		}
+		return true
+	}
+	i := r.index(p)
+	if i < 0 && m.Type != MsgJoin && m.Type != MsgWatch {
+		return false
+	}
+	switch m.Type {
+	case MsgJoin:
+		if i >= 0 {
+			break
+		}
+		if len(r.players) == 2 {
+			p.send(Message{Type: MsgError, Error: "the room is full"})
+			return false
+		}
+		r.players = append(r.players, p)
+		r.ready, r.rematch, r.results = [2]bool{}, [2]bool{}, false
+		r.broadcast()
+	case MsgWatch:
+		if i >= 0 {
+			break
+		}
+		if len(r.spectators) == maxSpectators {
+			p.send(Message{Type: MsgError, Error: "the room has enough spectators"})
+			return false
+		}
+		r.spectators = append(r.spectators, p)
+		if r.sim != nil {
+			p.send(Message{Type: MsgStart, Rules: &r.rules, Spectating: true})
+		}
+		r.broadcast()
+	case MsgLeave:
+		r.leave(i)
+		return false
+	case MsgReady:
+		if r.sim != nil || r.results {
+			break
+		}
+		r.ready[i] = m.Ready
+		if len(r.players) == 2 && r.ready[0] && r.ready[1] {
+			r.startMatch()
+			break
+		}
+		r.broadcast()
+	case MsgSettings:
+		switch {
+		case i != 0:
+			p.send(Message{Type: MsgError, Error: "only player one can change the settings"})
+		case r.sim != nil || m.Settings == nil || !m.Settings.Valid():
+			p.send(Message{Type: MsgError, Error: "invalid settings"})
+		default:
+			r.settings = *m.Settings
+			r.ready = [2]bool{}
+			r.broadcast()
+		}
+	case MsgRematch:
+		if !r.results {
+			break
+		}
+		r.rematch[i] = true
+		if r.rematch[0] && r.rematch[1] {
+			r.startMatch()
+			break
+		}
+		r.broadcast()
+	case MsgChat, MsgEmote:
+		r.chat(p, i, m)
+	case MsgTurn:
+		if r.sim != nil && len(r.turns) < maxTurns {
+			r.turns = append(r.turns, game.Turn{Player: i, Direction: m.Direction})
+			r.seqs[i] = m.Seq
+		}
+	}
+	return true
+}
+
+// chat relays the chat message or emote m of p, player i or -1 for a
+// spectator.
+func (r *room) chat(p *player, i int, m Message) {
+	if !p.chat.allow(time.Now()) {
+		p.send(Message{Type: MsgError, Error: "you are chatting too fast"})
+		return
+	}
+	out := Message{Type: m.Type, Player: i}
+	if m.Type == MsgEmote {
+		if m.Emote < 0 || m.Emote >= len(Emotes) {
+			return
+		}
+		out.Emote = m.Emote
+		r.send(out)
+		return
+	}
+	text, ok := cleanChat(m.Text), true
+	if f := r.s.Filter; f != nil && text != "" {
+		text, ok = f(text)
+	}
+	if !ok || text == "" {
+		return
+	}
+	out.Text = text
+	r.send(out)
+}
+
+// index returns the player number of p, -1 if p is not a player of the
+// room.
+func (r *room) index(p *player) int {
+	return find(r.players, p)
+}
+
+// find returns the index of p in clients, -1 if it is missing.
+func find(clients []*player, p *player) int {
+	for i, q := range clients {
+		if q == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// leave lets player i leave. If a match is played, the other player
+// wins it.
+func (r *room) leave(i int) {
+	r.players = append(r.players[:i], r.players[i+1:]...)
+	if r.sim != nil {
+		r.endMatch()
+		r.send(Message{Type: MsgOver, Winner: 2 - i, Left: true})
+	}
+	r.ready, r.rematch, r.results = [2]bool{}, [2]bool{}, false
+	r.broadcast()
+}
+
+// startMatch starts a match with the settings of the room.
+func (r *room) startMatch() {
+	rules := r.settings.rules(r.s.base)
+	r.sim, r.rules = game.New(rules, time.Now().UnixNano()), rules
+	r.turns = nil
+	r.seqs, r.acks = [2]int{}, [2]int{}
+	r.ready, r.rematch, r.results = [2]bool{}, [2]bool{}, false
+	for i, p := range r.players {
+		p.send(Message{Type: MsgStart, Player: i, Rules: &rules})
+	}
+	for _, p := range r.spectators {
+		p.send(Message{Type: MsgStart, Rules: &rules, Spectating: true})
+	}
+	r.start = time.NewTimer(StartDelay)
+	r.broadcast()
+}
+
+// advance runs a tick of the match and sends the arena to the players.
+func (r *room) advance() {
+	r.sim.Tick(r.turns...)
+	r.turns = r.turns[:0]
+	r.acks = r.seqs
+	sv := r.sim.Snapshot()
+	for i, p := range r.players {
+		p.send(Message{Type: MsgSnapshot, Snapshot: &sv, Seq: r.acks[i]})
+	}
+	for _, p := range r.spectators {
+		p.send(Message{Type: MsgSnapshot, Snapshot: &sv})
+	}
+	winner, over := r.settings.winner(r.sim, r.s.tickRate)
+	if !over {
+		return
+	}
+	r.endMatch()
+	r.results = true
+	r.send(Message{Type: MsgOver, Winner: winner})
+	r.broadcast()
+}
+
+// endMatch stops the match played.
+func (r *room) endMatch() {
+	if r.start != nil {
+		r.start.Stop()
+	}
+	if r.tick != nil {
+		r.tick.Stop()
+	}
+	r.sim, r.start, r.tick = nil, nil, nil
+}
+
+// send sends m to the players and the spectators.
+func (r *room) send(m Message) {
+	for _, p := range r.players {
+		p.send(m)
+	}
+	m.Spectating = true
+	for _, p := range r.spectators {
+		p.send(m)
+	}
+}
+
+// broadcast sends the state of the room to its players and spectators.
+func (r *room) broadcast() {
+	room := &Room{
+		Code:       r.code,
+		Settings:   r.settings,
+		Players:    len(r.players),
+		Ready:      r.ready,
+		Spectators: len(r.spectators),
+		Playing:    r.sim != nil,
+		Results:    r.results,
+		Rematch:    r.rematch,
+	}
+	for i, p := range r.players {
+		p.send(Message{Type: MsgRoom, Player: i, Room: room})
+	}
+	for _, p := range r.spectators {
+		p.send(Message{Type: MsgRoom, Room: room, Spectating: true})
+	}
+}
+
+// player is a connected client.
+type player struct {
+	conn *websocket.Conn
+	// in are the messages received, closed once the connection is lost
+	in chan Message
+	// chat limits the chat messages, it is only touched by the room
+	chat limiter
+}
+
+// read reads the messages of the client until the connection is lost.
+// Clients not answering the pings are dropped.
+func (p *player) read() {
+	defer close(p.in)
+	done := make(chan struct{})
+	defer close(done)
+	p.conn.SetReadLimit(readLimit)
+	p.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	p.conn.SetPongHandler(func(string) error {
+		return p.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	go p.ping(done)
+	for {
+		var m Message
+		if err := p.conn.ReadJSON(&m); err != nil {
+			return
+		}
+		p.in <- m
+	}
+}
+
+// ping pings the client every pingInterval until done is closed.
+func (p *player) ping(done <-chan struct{}) {
+	t := time.NewTicker(pingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			if err := p.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send sends m to the client. A lost connection is noticed by read, so
+// errors are only logged.
+func (p *player) send(m Message) {
+	p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := p.conn.WriteJSON(m); err != nil {
+		log.Printf("could not send to %s: %v", p.conn.RemoteAddr(), err)
+	}
+}
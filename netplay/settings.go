@@ -0,0 +1,138 @@
+package netplay
+
+import (
+	"fmt"
+
+	"github.com/wongak/snake/game"
+)
+
+// The conditions winning a match.
+const (
+	// WinCrash wins the match for the last snake standing.
+	WinCrash = iota
+	// WinPoints wins the match for the first snake to score Target
+	// points.
+	WinPoints
+	// WinTime wins the match for the snake with the most points after
+	// Target seconds.
+	WinTime
+	// WinShrink wins the match for the last snake standing in an arena
+	// closing in every game.ShrinkInterval ticks.
+	WinShrink
+)
+
+// Settings are the rules of the matches of a room, chosen by the player
+// who created it.
+type Settings struct {
+	Arena int `json:"arena"`
+	Speed int `json:"speed"`
+	Win   int `json:"win"`
+	// Target is the index of the points or the time of WinPoints and
+	// WinTime.
+	Target int `json:"target"`
+}
+
+// Arena is a board size a room can be played on.
+type Arena struct {
+	Name           string
+	CellsX, CellsY int
+}
+
+// Arenas are the board sizes of the rooms.
+var Arenas = []Arena{
+	{"small", 30, 20},
+	{"medium", 40, 30},
+	{"large", 60, 40},
+}
+
+// Speed is a speed a room can be played at.
+type Speed struct {
+	Name string
+	// Frames is the number of ticks between two steps.
+	Frames float64
+}
+
+// Speeds are the speeds of the rooms.
+var Speeds = []Speed{
+	{"slow", 12},
+	{"normal", 8},
+	{"fast", 5},
+}
+
+// WinNames are the names of the win conditions.
+var WinNames = []string{
+	WinCrash:  "last snake standing",
+	WinPoints: "first to the points",
+	WinTime:   "most points in time",
+	WinShrink: "shrinking arena",
+}
+
+var (
+	// TargetPoints are the points of WinPoints.
+	TargetPoints = []int64{10000, 25000, 50000}
+	// TargetSeconds are the times of WinTime.
+	TargetSeconds = []int{60, 120, 300}
+)
+
+// DefaultSettings are the settings of a new room.
+var DefaultSettings = Settings{Arena: 1, Speed: 1}
+
+// Valid reports whether the settings are among the ones offered.
+func (s Settings) Valid() bool {
+	return s.Arena >= 0 && s.Arena < len(Arenas) &&
+		s.Speed >= 0 && s.Speed < len(Speeds) &&
+		s.Win >= WinCrash && s.Win <= WinShrink &&
+		s.Target >= 0 && s.Target < len(TargetPoints)
+}
+
+// String describes the win condition.
+func (s Settings) String() string {
+	switch s.Win {
+	case WinPoints:
+		return fmt.Sprintf("first to %d points", TargetPoints[s.Target])
+	case WinTime:
+		return fmt.Sprintf("most points in %d seconds", TargetSeconds[s.Target])
+	case WinShrink:
+		return "last snake standing in a shrinking arena"
+	}
+	return WinNames[WinCrash]
+}
+
+// rules returns the rules of a match with these settings, on top of
+// base.
+func (s Settings) rules(base game.Rules) game.Rules {
+	r := base
+	a := Arenas[s.Arena]
+	r.CellsX, r.CellsY = a.CellsX, a.CellsY
+	r.Speed = Speeds[s.Speed].Frames
+	r.Shrink = s.Win == WinShrink
+	return r
+}
+
+// winner returns the winner of a match with these settings once it is
+// decided, 1 or 2 or 0 on a draw, and whether it is over.
+func (s Settings) winner(sim *game.Game, tickRate int) (int, bool) {
+	if sim.Over() {
+		return sim.Winner(), true
+	}
+	one, two := sim.Points(), sim.RivalPoints()
+	switch s.Win {
+	case WinPoints:
+		if one < TargetPoints[s.Target] && two < TargetPoints[s.Target] {
+			return 0, false
+		}
+	case WinTime:
+		if sim.Frame() < int64(TargetSeconds[s.Target]*tickRate) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	switch {
+	case one > two:
+		return 1, true
+	case two > one:
+		return 2, true
+	}
+	return 0, true
+}
@@ -0,0 +1,102 @@
+package snake
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/wongak/snake/leaderboard"
+)
+
+// globalTop is the number of entries of the online leaderboard shown.
+const globalTop = 20
+
+// globalScores is the answer of the online leaderboard to a score.
+// rejected is set if the leaderboard did not accept it.
+type globalScores struct {
+	top      []leaderboard.Entry
+	rejected bool
+	err      error
+}
+
+// submitScore posts the score of the run under name to the online
+// leaderboard and fetches its best entries in the background. The
+// score goes with the recording of the run for the leaderboard to
+// verify it. Runs not played with the rules of their mode are not
+// submitted, the leaderboard turns them away, see leaderboard.ModeRules.
+func (g *Game) submitScore(name string) {
+	if g.leaderboard == nil || g.ended == nil {
+		return
+	}
+	c := g.leaderboard
+	e := leaderboard.Entry{Name: name, Score: g.ended.Score, Mode: g.scoreMode(), Run: leaderboardRun(g.ended)}
+	if leaderboard.CheckRules(e.Mode, e.Run.Rules) != nil {
+		return
+	}
+	done := make(chan globalScores, 1)
+	g.global, g.globalDone = nil, done
+	go func() {
+		var s globalScores
+		s.err = c.Submit(e)
+		if errors.Is(s.err, leaderboard.ErrRejected) {
+			log.Print(s.err)
+			s.rejected, s.err = true, nil
+		}
+		if s.err == nil {
+			s.top, s.err = c.Top(e.Mode, globalTop)
+		}
+		done <- s
+	}()
+}
+
+// leaderboardRun returns the run of replay r for the leaderboard to
+// verify.
+func leaderboardRun(r *Replay) *leaderboard.Run {
+	run := &leaderboard.Run{Rules: r.Rules, Seed: r.Seed}
+	for _, t := range r.Turns {
+		if t.Player == 0 {
+			run.Turns = append(run.Turns, leaderboard.Turn{Frame: t.Frame, Direction: t.Direction})
+		}
+	}
+	return run
+}
+
+// updateLeaderboard takes the answer of the online leaderboard once it
+// arrived.
+func (g *Game) updateLeaderboard() {
+	if g.globalDone == nil {
+		return
+	}
+	select {
+	case s := <-g.globalDone:
+		if s.err != nil {
+			log.Printf("could not reach the leaderboard: %v", s.err)
+		}
+		g.global, g.globalDone = &s, nil
+	default:
+	}
+}
+
+// globalLines returns the lines of the game over screen showing the
+// online leaderboard, and whether they replace the local high scores.
+// Without an answer the local high scores are shown instead.
+func (g *Game) globalLines() ([]string, bool) {
+	switch {
+	case g.leaderboard == nil:
+		return nil, false
+	case g.globalDone != nil:
+		return []string{"loading the global top scores..."}, false
+	case g.global == nil:
+		return nil, false
+	case g.global.err != nil:
+		return []string{"leaderboard offline, local high scores:"}, false
+	}
+	lines := []string{fmt.Sprintf("global top %d", globalTop)}
+	if g.global.rejected {
+		lines[0] += " - your score could not be verified"
+	}
+	for i, e := range g.global.top {
+		lines = append(lines, fmt.Sprintf("%2d. %-3s %8s  %s", i+1, e.Name, g.formatScore(e.Score), e.Date.Format("2006-01-02")))
+	}
+	return lines, true
+}
@@ -0,0 +1,159 @@
+package snake
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// maxParticles is the size of the particle pool. Bursts beyond it are
+// cut short.
+const maxParticles = 512
+
+// particleDrag slows the particles down every frame.
+const particleDrag = 0.92
+
+// particle is a colored square flying off from a burst.
+type particle struct {
+	x, y   float64
+	vx, vy float64
+	size   float64
+	life   int
+	// maxLife is the life it started with
+	maxLife int
+	clr     color.RGBA
+}
+
+// particles is a fixed pool of particles, the first n of which are
+// alive. They move every frame, not every tick, so they keep flying on
+// the game over screen.
+type particles struct {
+	pool [maxParticles]particle
+	n    int
+	dot  *ebiten.Image
+	opts ebiten.DrawImageOptions
+}
+
+func newParticles() *particles {
+	ps := &particles{}
+	ps.dot, _ = ebiten.NewImage(1, 1, ebiten.FilterNearest)
+	ps.dot.Fill(color.White)
+	return ps
+}
+
+// emit adds p if the pool has room.
+func (ps *particles) emit(p particle) {
+	if ps.n == len(ps.pool) {
+		return
+	}
+	p.maxLife = p.life
+	ps.pool[ps.n] = p
+	ps.n++
+}
+
+// burst emits n particles of size pixels flying off from (x, y) in all
+// directions at up to speed pixels per frame.
+func (ps *particles) burst(x, y float64, n int, size, speed float64, life int, clr color.RGBA) {
+	for i := 0; i < n; i++ {
+		a := rand.Float64() * 2 * math.Pi
+		v := speed * (0.5 + rand.Float64()/2)
+		ps.emit(particle{x: x, y: y, vx: v * math.Cos(a), vy: v * math.Sin(a), size: size, life: life, clr: clr})
+	}
+}
+
+// update moves the particles and drops the dead ones.
+func (ps *particles) update() {
+	for i := 0; i < ps.n; {
+		p := &ps.pool[i]
+		p.life--
+		if p.life <= 0 {
+			ps.n--
+			ps.pool[i] = ps.pool[ps.n]
+			continue
+		}
+		p.x, p.y = p.x+p.vx, p.y+p.vy
+		p.vx, p.vy = p.vx*particleDrag, p.vy*particleDrag
+		i++
+	}
+}
+
+func (ps *particles) clear() {
+	ps.n = 0
+}
+
+// draw draws the particles shrinking and fading out, shifted by the
+// camera offset.
+func (ps *particles) draw(canvas *ebiten.Image, camX, camY float64) {
+	for i := 0; i < ps.n; i++ {
+		p := &ps.pool[i]
+		f := float64(p.life) / float64(p.maxLife)
+		s := p.size * (0.5 + f/2)
+		ps.opts.GeoM.Reset()
+		ps.opts.GeoM.Scale(s, s)
+		ps.opts.GeoM.Translate(p.x-s/2-camX, p.y-s/2-camY)
+		ps.opts.ColorM.Reset()
+		ps.opts.ColorM.Scale(float64(p.clr.R)/0xff, float64(p.clr.G)/0xff, float64(p.clr.B)/0xff, f)
+		canvas.DrawImage(ps.dot, &ps.opts)
+	}
+}
+
+// burstFood bursts particles in the color of the food eaten on cell
+// (x, y).
+func (g *Game) burstFood(x, y int, t *game.FoodType) {
+	w := g.w
+	px, py := w.cellToPixel(x, y)
+	g.particles.burst(px+float64(w.cellW)/2, py+float64(w.cellH)/2, 12, float64(w.cellW)/3, float64(w.cellW)/6, 30, w.foodColor(t))
+}
+
+// scatter blows the segments of a crashed snake apart.
+func (g *Game) scatter(s *game.Snake, clr color.RGBA) {
+	w := g.w
+	for i := 0; i < s.Len(); i++ {
+		x, y := w.segmentToPixel(s, i, 1)
+		g.particles.burst(x+float64(w.cellW)/2, y+float64(w.cellH)/2, 1, float64(w.cellW), float64(w.cellW)/4, 60, clr)
+	}
+}
+
+// burstCrash bursts the snake of player at the cell it crashed on.
+func (g *Game) burstCrash(x, y, player int) {
+	w := g.w
+	clr := w.theme.Snake
+	if player == 1 {
+		clr = w.theme.Rival
+	}
+	px, py := w.cellToPixel(x, y)
+	g.particles.burst(px+float64(w.cellW)/2, py+float64(w.cellH)/2, 24, float64(w.cellW)/2, float64(w.cellW)/4, 60, clr)
+}
+
+// crashed reports whether the snake of player crashed at the end of the
+// run. In two-player games the winner stays in one piece, co-op runs
+// end with both snakes scattered.
+func (g *Game) crashed(player int) bool {
+	sim := g.sim
+	if !sim.Over() || sim.Won() {
+		return false
+	}
+	if g.options.Coop {
+		return true
+	}
+	if !g.options.TwoPlayer {
+		return player == 0
+	}
+	winner := sim.Winner()
+	return player == 0 && winner != 1 || player == 1 && winner != 2
+}
+
+// scatterCrashed scatters the snakes which crashed. They are no longer
+// drawn in one piece.
+func (g *Game) scatterCrashed() {
+	sim, t := g.sim, g.w.theme
+	if g.crashed(0) {
+		g.scatter(sim.Snake(), t.Snake)
+	}
+	if r := sim.Rival(); r != nil && g.crashed(1) {
+		g.scatter(r, t.Rival)
+	}
+}
@@ -0,0 +1,103 @@
+package snake
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// DefaultIdlePause is the time without input after which a run is
+// paused unless Options.IdlePause is set.
+const DefaultIdlePause = time.Minute
+
+// The entries of the pause menu.
+const (
+	pauseResume = iota
+	pauseRestart
+	pauseSettings
+	pauseQuit
+	pauseEntries
+)
+
+var pauseLabels = [pauseEntries]string{
+	pauseResume:   "Resume",
+	pauseRestart:  "Restart",
+	pauseSettings: "Settings",
+	pauseQuit:     "Quit to menu",
+}
+
+// pause holds the running game with the pause menu open on Resume.
+func (g *Game) pause() {
+	g.state = StatePaused
+	g.pauseEntry = pauseResume
+}
+
+// idleTooLong counts the frames of the run without input and reports whether
+// there were Options.IdlePause of them. Runs not steered by the player
+// are never idle.
+func (g *Game) idleTooLong() bool {
+	o := g.options
+	if o.IdlePause <= 0 || o.Autopilot != nil || g.replay != nil || g.net != nil || g.demo {
+		return false
+	}
+	_, held := gamepadDirection(o.DeadZone)
+	for _, st := range steering {
+		held = held || o.Keys.pressed(st.action)
+	}
+	if held || anyInput() {
+		g.idleFrames = 0
+		return false
+	}
+	g.idleFrames++
+	if g.idleFrames < int(o.IdlePause*DefaultTickRate/time.Second) {
+		return false
+	}
+	g.idleFrames = 0
+	return true
+}
+
+// updatePaused handles the pause menu. The entries are picked with the
+// up and down keys or the D-pad and selected with enter. The pause key
+// or the start button resume without the menu, back quits to the menu.
+func (g *Game) updatePaused(back, enter bool) {
+	keys := g.options.Keys
+	switch {
+	case back:
+		g.state = StateMenu
+	case keys.justPressed(ActionPause) || gamepadJustPressed(gamepadStart):
+		g.resume()
+	case keys.justPressed(ActionUp) || gamepadJustPressed(gamepadUp):
+		g.pauseEntry = (g.pauseEntry + pauseEntries - 1) % pauseEntries
+	case keys.justPressed(ActionDown) || gamepadJustPressed(gamepadDown):
+		g.pauseEntry = (g.pauseEntry + 1) % pauseEntries
+	case enter:
+		g.selectPause()
+	}
+}
+
+// selectPause runs the selected entry of the pause menu.
+func (g *Game) selectPause() {
+	switch g.pauseEntry {
+	case pauseResume:
+		g.resume()
+	case pauseRestart:
+		g.Reset()
+	case pauseSettings:
+		g.openSettings(StatePaused)
+	case pauseQuit:
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) drawPaused(canvas *ebiten.Image) {
+	lines := []string{"Paused"}
+	for i, label := range pauseLabels {
+		prefix := "  "
+		if i == g.pauseEntry {
+			prefix = "> "
+		}
+		lines = append(lines, prefix+label)
+	}
+	lines = append(lines, "arrows choose, Enter selects, P resumes")
+	g.drawOverlay(canvas, lines...)
+}
@@ -0,0 +1,48 @@
+package snake
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// powerupColors are the tile colors of the power-up kinds.
+var powerupColors = [...]color.RGBA{
+	game.PowerupFast:   {0xff, 0xd0, 0x20, 0xff},
+	game.PowerupSlow:   {0x40, 0x80, 0xff, 0xff},
+	game.PowerupShrink: {0xff, 0x80, 0x20, 0xff},
+	game.PowerupGhost:  {0xe0, 0xe0, 0xff, 0xff},
+}
+
+// powerupTiles creates a tile per power-up kind.
+func powerupTiles(w *world) []*ebiten.Image {
+	tiles := make([]*ebiten.Image, game.PowerupKinds)
+	for i := range tiles {
+		tiles[i] = w.itemTile(shapePlus, powerupColors[i])
+	}
+	return tiles
+}
+
+func (g *Game) drawPowerup(canvas *ebiten.Image) {
+	p := g.sim.Powerup()
+	if p == nil {
+		return
+	}
+	// blink before disappearing
+	if p.Life < 2*60 && p.Life/8%2 == 0 {
+		return
+	}
+	g.w.drawFoodTile(canvas, &p.Food, g.powerupTiles[p.Effect])
+}
+
+// drawEffect shows the running effect and its remaining seconds.
+func (g *Game) drawEffect(canvas *ebiten.Image) {
+	effect, left := g.sim.Effect()
+	if left == 0 {
+		return
+	}
+	str := fmt.Sprintf("%s %.1fs", effect, float64(left)/60)
+	g.drawText(canvas, str, g.w.cellW*2, g.hudRow(5), powerupColors[effect], 1)
+}
@@ -0,0 +1,194 @@
+package snake
+
+import (
+	"log"
+
+	"github.com/wongak/snake/game"
+)
+
+const (
+	// defaultRTT is the round trip time to the server assumed until the
+	// first turn came back, in ticks.
+	defaultRTT = 6
+	// maxLead is the number of ticks the local run is ahead of the
+	// server at most.
+	maxLead = 30
+	// maxExtrapolation is the number of ticks the opponent goes on
+	// without a snapshot before it stops.
+	maxExtrapolation = 15
+)
+
+// prediction runs an online match locally ahead of the server, so the
+// snake of the local player turns without waiting for the server.
+// Every snapshot replaces the local run, which then plays the turns
+// the server has not applied yet again. The opponent is shown as the
+// server last sent it instead, since their turns are not known ahead.
+type prediction struct {
+	// pending are the turns sent the server has not applied yet, seq
+	// is the last turn sent.
+	pending []pendingTurn
+	seq     int
+	// snapshot is the last snapshot received, fresh until the local
+	// run was reconciled with it.
+	snapshot *game.Snapshot
+	fresh    bool
+	// remote is the run as the server last sent it, age ticks ago.
+	remote *game.Game
+	age    int
+	// ticks counts the ticks of the match, rtt is the round trip time
+	// in ticks, smoothed.
+	ticks int64
+	rtt   float64
+	// watch is set for spectators, who do not play ahead
+	watch bool
+}
+
+// pendingTurn is a turn of the local player the server has not
+// applied yet, with the frame it is predicted on and the tick it was
+// sent on.
+type pendingTurn struct {
+	seq       int
+	frame     int64
+	direction int
+	sent      int64
+}
+
+func newPrediction(rules game.Rules, watch bool) prediction {
+	return prediction{remote: game.New(rules, 0), rtt: defaultRTT, watch: watch}
+}
+
+// lead returns the number of ticks the local run is ahead of the last
+// snapshot. The turns sent reach the server about one round trip after
+// that snapshot was taken.
+func (p *prediction) lead() int64 {
+	if p.watch {
+		return 1
+	}
+	lead := int64(p.rtt+0.5) + 1
+	if lead > maxLead {
+		return maxLead
+	}
+	return lead
+}
+
+// receive takes a snapshot of the server having applied the turns up
+// to seq.
+func (p *prediction) receive(sv *game.Snapshot, seq int) {
+	p.snapshot, p.fresh = sv, true
+	if err := p.remote.Restore(*sv); err != nil {
+		log.Printf("invalid snapshot: %v", err)
+		return
+	}
+	p.age = 0
+	n := 0
+	for _, t := range p.pending {
+		if t.seq > seq {
+			p.pending[n] = t
+			n++
+			continue
+		}
+		if t.seq == seq {
+			p.rtt += (float64(p.ticks-t.sent) - p.rtt) / 8
+		}
+	}
+	p.pending = p.pending[:n]
+}
+
+// turnsAt returns the pending turns of player predicted on frame.
+func (p *prediction) turnsAt(frame int64, player int) []game.Turn {
+	var turns []game.Turn
+	for _, t := range p.pending {
+		if t.frame == frame {
+			turns = append(turns, game.Turn{Player: player, Direction: t.direction})
+		}
+	}
+	return turns
+}
+
+// sendTurns sends the turns of the local player to the server and
+// predicts them on the next frame. A direction held is sent once.
+func (g *Game) sendTurns(turns []game.Turn) {
+	p := &g.pred
+	for _, t := range turns {
+		if t.Direction == g.netTurn {
+			continue
+		}
+		if err := g.net.Turn(t.Direction, p.seq+1); err != nil {
+			log.Printf("could not send a turn: %v", err)
+			return
+		}
+		p.seq++
+		p.pending = append(p.pending, pendingTurn{seq: p.seq, frame: g.sim.Frame() + 1, direction: t.Direction, sent: p.ticks})
+		g.netTurn = t.Direction
+	}
+}
+
+// predict advances the local run by one tick, reconciling it with the
+// last snapshot first, and extrapolates the opponent.
+func (g *Game) predict() {
+	p := &g.pred
+	if p.watch && p.snapshot == nil {
+		// spectators wait for the arena
+		return
+	}
+	p.ticks++
+	g.reconcile()
+	g.sim.Tick(p.turnsAt(g.sim.Frame()+1, g.netPlayer)...)
+	if p.age < maxExtrapolation {
+		p.remote.Tick()
+		p.age++
+	}
+}
+
+// reconcile replaces the local run with the last snapshot and plays
+// the pending turns again up to the frame before the one predicted
+// next. Turns predicted on frames the server already played go on the
+// next one.
+func (g *Game) reconcile() {
+	p := &g.pred
+	if !p.fresh {
+		return
+	}
+	p.fresh = false
+	sv := p.snapshot
+	if err := g.sim.Restore(*sv); err != nil {
+		log.Printf("invalid snapshot: %v", err)
+		return
+	}
+	next := sv.Frame + p.lead()
+	for i := range p.pending {
+		t := &p.pending[i]
+		switch {
+		case t.frame <= sv.Frame:
+			t.frame = sv.Frame + 1
+		case t.frame > next:
+			t.frame = next
+		}
+	}
+	for f := sv.Frame + 1; f < next; f++ {
+		g.sim.Tick(p.turnsAt(f, g.netPlayer)...)
+	}
+}
+
+// confirm shows the run as the server last sent it, for the result of
+// a match.
+func (g *Game) confirm() {
+	if sv := g.pred.snapshot; sv != nil {
+		if err := g.sim.Restore(*sv); err != nil {
+			log.Printf("invalid snapshot: %v", err)
+		}
+	}
+}
+
+// shownSnake returns the snake of player to draw and the run it moves
+// in. Online the opponent is drawn as the server last sent it.
+func (g *Game) shownSnake(player int) (*game.Snake, *game.Game) {
+	sim := g.sim
+	if g.net != nil && player != g.netPlayer && g.pred.remote != nil {
+		sim = g.pred.remote
+	}
+	if player == 1 {
+		return sim.Rival(), sim
+	}
+	return sim.Snake(), sim
+}
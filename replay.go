@@ -0,0 +1,210 @@
+package snake
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/game"
+)
+
+// replaySpeeds are the speeds a replay is played back at, as multiples
+// of the speed of the run, normalSpeed the index of the speed of the
+// run.
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8}
+
+const normalSpeed = 2
+
+// scrubber are the playback controls of a replay.
+type scrubber struct {
+	paused bool
+	// speed is the index of the playback speed in replaySpeeds counted
+	// from normalSpeed
+	speed int
+	// due are the ticks due at the playback speed not run yet, less
+	// than one
+	due float64
+}
+
+// ticks returns the number of ticks of the replay to run for n ticks
+// of the clock.
+func (sc *scrubber) ticks(n int) int {
+	if sc.paused {
+		return 0
+	}
+	sc.due += float64(n) * replaySpeeds[normalSpeed+sc.speed]
+	k := int(sc.due)
+	sc.due -= float64(k)
+	return k
+}
+
+// Replay is the recording of a run: the rules and the seed it started
+// with and the turns of the players. As the game is deterministic given
+// these, playing a replay back re-simulates the run. The computer snake
+// is steered by the Options.Bot of the playback, so replays of games
+// against other bots than bot.Greedy need that bot again.
+type Replay struct {
+	Rules game.Rules `json:"rules"`
+	Seed  int64      `json:"seed"`
+	Turns []Turn     `json:"turns"`
+	// Score are the points of player one at the end of the run.
+	Score int64 `json:"score"`
+}
+
+// Turn is a turn of a player's snake. Only turns the snake accepted are
+// recorded.
+type Turn struct {
+	Frame int64 `json:"f"`
+	// Player is 0 for player one and 1 for player two.
+	Player    int `json:"p,omitempty"`
+	Direction int `json:"d"`
+}
+
+// LoadReplay reads a replay written by Replay.Save. Levels are loaded
+// again by name, campaigns play the built-in campaign levels.
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.NewDecoder(zr).Decode(r); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(r.Rules.Campaign) > 0:
+		if r.Rules.Campaign, err = game.CampaignLevels(); err != nil {
+			return nil, err
+		}
+		r.Rules.Level = nil
+	case r.Rules.Level != nil:
+		if r.Rules.Level, err = game.LoadLevel(r.Rules.Level.Name); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Save writes the replay to path as gzipped JSON.
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// seedRun returns the seed of a new run.
+func (g *Game) seedRun() int64 {
+	switch {
+	case g.replay != nil:
+		return g.replay.Seed
+	case g.options.Daily:
+		return game.DailySeed(dailyDate())
+	case g.options.Seed != 0:
+		return g.options.Seed
+	}
+	return time.Now().UnixNano()
+}
+
+// record adds a turn accepted by the snake to the recording.
+func (g *Game) record(e game.Event) {
+	if g.recording != nil {
+		g.recording.Turns = append(g.recording.Turns, Turn{Frame: g.sim.Frame(), Player: e.Player, Direction: e.Direction})
+	}
+}
+
+// replayTurns returns the recorded turns of the next tick of sim,
+// advancing *at past them.
+func replayTurns(r *Replay, at *int, sim *game.Game) []game.Turn {
+	var turns []game.Turn
+	for ; *at < len(r.Turns) && r.Turns[*at].Frame <= sim.Frame()+1; *at++ {
+		t := r.Turns[*at]
+		turns = append(turns, game.Turn{Player: t.Player, Direction: t.Direction})
+	}
+	return turns
+}
+
+// endRecording finishes the recording of the ended run, passes it to
+// the OnReplay hook and keeps it if it is the best run.
+func (g *Game) endRecording() {
+	r := g.recording
+	if r == nil || g.demo {
+		return
+	}
+	g.recording, g.ended = nil, r
+	r.Score = g.sim.Points()
+	if g.options.OnReplay != nil {
+		g.options.OnReplay(r)
+	}
+	g.keepBest(r)
+}
+
+// updateScrubber handles the playback controls of a replay: Space
+// pauses it, Left and Right step back and forth by one tick while it is
+// paused and Up and Down change the speed.
+func (g *Game) updateScrubber() {
+	sc := &g.scrub
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		sc.paused = !sc.paused
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp) && normalSpeed+sc.speed < len(replaySpeeds)-1:
+		sc.speed++
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown) && normalSpeed+sc.speed > 0:
+		sc.speed--
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight) && sc.paused:
+		g.update()
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft) && sc.paused:
+		g.seekReplay(g.sim.Frame() - 1)
+	}
+}
+
+// seekReplay plays the replay from the start up to frame, without
+// sounds and effects, to step back in it. The game is deterministic,
+// so this ends in the same state as playing it up to there.
+func (g *Game) seekReplay(frame int64) {
+	g.sim = game.New(g.options.rules(g.campaign), g.replay.Seed)
+	g.replayAt = 0
+	for g.sim.Frame() < frame && !g.sim.Over() {
+		if g.sim.LevelComplete() {
+			g.sim.NextLevel()
+			continue
+		}
+		g.sim.Tick(replayTurns(g.replay, &g.replayAt, g.sim)...)
+	}
+	g.state = StatePlaying
+	g.countdown = 0
+	g.anims.clear()
+	g.particles.clear()
+	h := g.sim.Snake().Head()
+	g.w.follow(h.X, h.Y)
+}
+
+func (g *Game) drawReplay(canvas *ebiten.Image) {
+	w := g.w
+	sc := g.scrub
+	status := fmt.Sprintf("REPLAY tick %d  x%g", g.sim.Frame(), replaySpeeds[normalSpeed+sc.speed])
+	if sc.paused {
+		status += "  paused"
+	}
+	g.drawText(canvas, status, w.cellW*2, g.hudRow(3), w.theme.Warning, 1)
+	g.drawText(canvas, "Space pauses, Left/Right step, Up/Down speed", w.cellW*2, g.hudRow(4), w.theme.Warning, 1)
+}
@@ -0,0 +1,146 @@
+package snake
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+// DefaultSaveFile returns the path of the quicksave in the user's
+// config directory.
+func DefaultSaveFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "snake", "save.json")
+}
+
+// savedGame is a running game as written by a quicksave. Only the modes
+// changeable on the menu are saved with it, the other options are taken
+// from the game loading it.
+type savedGame struct {
+	CellsX    int  `json:"cells_x"`
+	CellsY    int  `json:"cells_y"`
+	Walls     bool `json:"walls"`
+	Tron      bool `json:"tron"`
+	TwoPlayer bool `json:"two_player"`
+	Coop      bool `json:"coop,omitempty"`
+	Shrink    bool `json:"shrink,omitempty"`
+	Gauntlet  int  `json:"gauntlet,omitempty"`
+	AI        bool `json:"ai"`
+	Daily     bool `json:"daily"`
+	Campaign  bool `json:"campaign"`
+	game.Snapshot
+	Saved time.Time `json:"saved"`
+}
+
+// quicksave keeps the running game and writes it to the save file.
+func (g *Game) quicksave() {
+	o := g.options
+	cellsX, cellsY := g.sim.Size()
+	sv := &savedGame{
+		CellsX:    cellsX,
+		CellsY:    cellsY,
+		Walls:     o.Walls,
+		Tron:      o.Tron,
+		TwoPlayer: o.TwoPlayer,
+		Coop:      o.Coop,
+		Shrink:    o.Shrink,
+		Gauntlet:  o.Gauntlet,
+		AI:        o.AI,
+		Daily:     o.Daily,
+		Campaign:  o.Campaign,
+		Snapshot:  g.sim.Snapshot(),
+		Saved:     time.Now(),
+	}
+	g.saved = sv
+	g.showMessage("game saved")
+	if g.options.SaveFile == "" {
+		return
+	}
+	if err := sv.write(g.options.SaveFile); err != nil {
+		log.Printf("could not save the game: %v", err)
+	}
+}
+
+func (sv *savedGame) write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(sv, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// loadSave reads the quicksave at path. A missing file is no save.
+func loadSave(path string) (*savedGame, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sv := &savedGame{}
+	if err := json.Unmarshal(b, sv); err != nil {
+		return nil, err
+	}
+	return sv, nil
+}
+
+// quickload restores the last quicksave, paused so the player can get
+// ready. It reports whether there was a save to restore.
+func (g *Game) quickload() bool {
+	sv := g.saved
+	if sv == nil {
+		return false
+	}
+	if err := g.restore(sv); err != nil {
+		log.Printf("could not load the saved game: %v", err)
+		return false
+	}
+	g.pause()
+	return true
+}
+
+// restore replaces the running game with sv.
+func (g *Game) restore(sv *savedGame) error {
+	if x, y := g.sim.Size(); sv.CellsX != x || sv.CellsY != y {
+		return errors.New("the game was saved on another board")
+	}
+	if sv.Campaign && len(g.campaign) == 0 {
+		return errors.New("the game was saved in a campaign")
+	}
+	o := g.options
+	modeChanged := o.Walls != sv.Walls || o.Tron != sv.Tron || o.Coop != sv.Coop || o.Shrink != sv.Shrink || o.Gauntlet != sv.Gauntlet || o.Daily != sv.Daily || o.Campaign != sv.Campaign
+	o.Walls, o.Tron, o.TwoPlayer, o.AI, o.Daily, o.Campaign = sv.Walls, sv.Tron, sv.TwoPlayer, sv.AI, sv.Daily, sv.Campaign
+	o.Coop, o.Shrink, o.Gauntlet = sv.Coop, sv.Shrink, sv.Gauntlet
+	// the random numbers carry on from a fresh seed
+	sim := game.New(o.rules(g.campaign), time.Now().UnixNano())
+	if err := sim.Restore(sv.Snapshot); err != nil {
+		return err
+	}
+	g.options = o
+	if modeChanged {
+		g.loadScores()
+	}
+	g.sim = sim
+	g.demo = false
+	g.anims.clear()
+	// a restored run cannot be replayed
+	g.recording = nil
+	g.seed = 0
+	g.ghost = nil
+	h := g.sim.Snake().Head()
+	g.w.follow(h.X, h.Y)
+	return nil
+}
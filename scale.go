@@ -0,0 +1,36 @@
+package snake
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// letterbox is the color of the bars around an integer scaled screen.
+var letterbox = color.Black
+
+// integerScale returns the largest whole factor a screen of w by h
+// pixels can be scaled up by to fit into sw by sh pixels, at least 1.
+func integerScale(w, h, sw, sh int) int {
+	s := sw / w
+	if t := sh / h; t < s {
+		s = t
+	}
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+// drawScaled draws the game drawn to canvas onto screen, scaled up by
+// the largest whole factor fitting and centered between bars.
+func (g *Game) drawScaled(screen, canvas *ebiten.Image) {
+	w, h := canvas.Size()
+	sw, sh := screen.Size()
+	s := integerScale(w, h, sw, sh)
+	screen.Fill(letterbox)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(s), float64(s))
+	op.GeoM.Translate(float64((sw-w*s)/2), float64((sh-h*s)/2))
+	screen.DrawImage(canvas, op)
+}
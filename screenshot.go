@@ -0,0 +1,47 @@
+package snake
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// screenshotName returns the file name of a screenshot taken at t.
+func screenshotName(t time.Time) string {
+	return "snake-" + t.Format("20060102-150405.000") + ".png"
+}
+
+// updateScreenshot saves the screen drawn this update to a PNG file in
+// the working directory when F12 is pressed. The pixels are read back
+// from the screen image, which works the same in every build.
+func (g *Game) updateScreenshot(screen *ebiten.Image) {
+	if !inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		return
+	}
+	name := screenshotName(time.Now())
+	if err := saveScreenshot(name, screen); err != nil {
+		log.Printf("could not save screenshot: %v", err)
+		g.showMessage("could not save screenshot")
+		return
+	}
+	g.showMessage("saved " + name)
+}
+
+// saveScreenshot writes img to the PNG file path.
+func saveScreenshot(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,300 @@
+package snake
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/wongak/snake/audio"
+)
+
+// The entries of the settings screen.
+const (
+	settingSound = iota
+	settingMusic
+	settingEffects
+	settingTheme
+	settingSmooth
+	settingWalls
+	settingBoard
+	settingSpeed
+	settingControls
+)
+
+// boardPreset is a board size offered on the settings screen.
+type boardPreset struct {
+	name           string
+	cellsX, cellsY int
+}
+
+var boardPresets = []boardPreset{
+	{"small", 30, 20},
+	{"medium", 60, 40},
+	{"large", 90, 60},
+}
+
+// speedPreset is a starting speed offered on the settings screen.
+type speedPreset struct {
+	name  string
+	speed float64
+}
+
+var speedPresets = []speedPreset{
+	{"slow", 16},
+	{"normal", 12},
+	{"fast", 8},
+	{"very fast", 5},
+}
+
+// pendingRules are the walls and the speed changed on the settings
+// screen of a paused run. The rules of a run stay as they are, the next
+// run takes them over.
+type pendingRules struct {
+	set   bool
+	walls bool
+	speed float64
+}
+
+// rulesOptions returns the options with the pending walls and speed.
+func (g *Game) rulesOptions() Options {
+	o := g.options
+	if p := g.pending; p.set {
+		o.Walls, o.Speed = p.walls, p.speed
+	}
+	return o
+}
+
+// applyPending takes over the walls and the speed changed during the
+// last run.
+func (g *Game) applyPending() {
+	if !g.pending.set {
+		return
+	}
+	g.options = g.rulesOptions()
+	g.pending = pendingRules{}
+	g.loadScores()
+}
+
+// openSettings shows the settings screen, returning to from when it is
+// left.
+func (g *Game) openSettings(from GameState) {
+	g.state = StateSettings
+	g.settingsFrom = from
+	g.settingsEntry = 0
+}
+
+// settingEntries returns the entries of the settings screen. The rules
+// of a run cannot change while it is paused, so the walls and the speed
+// changed there apply to the next run, and the board is only offered
+// on the menu. The volumes are only offered with sound.
+func (g *Game) settingEntries() []int {
+	entries := []int{settingSound}
+	if g.sound != nil && g.options.Sound {
+		entries = append(entries, settingMusic, settingEffects)
+	}
+	entries = append(entries, settingTheme, settingSmooth)
+	if g.replay == nil && g.net == nil {
+		entries = append(entries, settingWalls)
+		if g.settingsFrom == StateMenu && g.options.Level == nil {
+			// levels have their own size
+			entries = append(entries, settingBoard)
+		}
+		entries = append(entries, settingSpeed)
+	}
+	return append(entries, settingControls)
+}
+
+// updateSettings handles the settings screen. Like the controls screen
+// it uses the arrow keys, Enter and Escape regardless of the keymap.
+// Up and down choose an entry, left and right or Enter change it.
+// Leaving the screen reports the options to OnOptionsChange.
+func (g *Game) updateSettings() {
+	entries := g.settingEntries()
+	if g.settingsEntry >= len(entries) {
+		// turning the sound off hides the volumes
+		g.settingsEntry = len(entries) - 1
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.state = g.settingsFrom
+		if g.options.OnOptionsChange != nil {
+			g.options.OnOptionsChange(g.rulesOptions())
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		g.settingsEntry = (g.settingsEntry + len(entries) - 1) % len(entries)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		g.settingsEntry = (g.settingsEntry + 1) % len(entries)
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		g.changeSetting(entries[g.settingsEntry], -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight), inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		g.changeSetting(entries[g.settingsEntry], 1)
+	}
+}
+
+// changeSetting changes the setting of entry one step up or down.
+func (g *Game) changeSetting(entry, step int) {
+	if (entry == settingWalls || entry == settingSpeed) && g.settingsFrom != StateMenu {
+		g.changePending(entry, step)
+		return
+	}
+	switch entry {
+	case settingSound:
+		g.toggleSound()
+	case settingMusic, settingEffects:
+		v := g.sound.Volume()
+		if entry == settingMusic {
+			v.Music += float64(step) * audio.VolumeStep
+		} else {
+			v.Effects += float64(step) * audio.VolumeStep
+		}
+		g.sound.SetVolume(v)
+		g.saveVolume()
+	case settingTheme:
+		g.options.Theme = shiftTheme(g.options.Theme, step)
+		g.initWorld()
+	case settingSmooth:
+		g.options.Smooth = !g.options.Smooth
+	case settingWalls:
+		g.options.Walls = !g.options.Walls
+		g.loadScores()
+	case settingBoard:
+		p := boardPresets[shiftPreset(g.boardPreset(), step, len(boardPresets))]
+		g.options.CellsX, g.options.CellsY = p.cellsX, p.cellsY
+		g.initWorld()
+		// the run behind the menu has the old size
+		g.Reset()
+		g.state = StateSettings
+	case settingSpeed:
+		g.options.Speed = speedPresets[shiftPreset(speedPresetOf(g.options.Speed), step, len(speedPresets))].speed
+	case settingControls:
+		g.state = StateControls
+		g.controls = ActionUp
+		g.controlsFrom = StateSettings
+	}
+}
+
+// changePending changes the walls or the speed for the next run.
+func (g *Game) changePending(entry, step int) {
+	if !g.pending.set {
+		g.pending = pendingRules{set: true, walls: g.options.Walls, speed: g.options.Speed}
+	}
+	p := &g.pending
+	if entry == settingWalls {
+		p.walls = !p.walls
+	} else {
+		p.speed = speedPresets[shiftPreset(speedPresetOf(p.speed), step, len(speedPresets))].speed
+	}
+}
+
+// toggleSound turns the music and the sound effects on or off. Sound
+// turned off is muted, as there is only one audio context.
+func (g *Game) toggleSound() {
+	g.options.Sound = !g.options.Sound
+	switch {
+	case g.options.Sound && g.sound == nil:
+		g.startSound()
+	case g.sound.Muted() == g.options.Sound:
+		g.sound.ToggleMute()
+	}
+}
+
+// shiftPreset returns the index n places after i among presets, the
+// first one if i is -1 for a size or speed set outside the screen.
+func shiftPreset(i, n, presets int) int {
+	if i < 0 {
+		return 0
+	}
+	i += n
+	if i < 0 {
+		return 0
+	}
+	if i >= presets {
+		return presets - 1
+	}
+	return i
+}
+
+// boardPreset returns the index of the board size preset, -1 if the
+// board has another size.
+func (g *Game) boardPreset() int {
+	for i, p := range boardPresets {
+		if p.cellsX == g.options.CellsX && p.cellsY == g.options.CellsY {
+			return i
+		}
+	}
+	return -1
+}
+
+// speedPresetOf returns the index of the preset of speed, -1 if there
+// is none.
+func speedPresetOf(speed float64) int {
+	for i, p := range speedPresets {
+		if p.speed == speed {
+			return i
+		}
+	}
+	return -1
+}
+
+// settingValue returns the current value of the setting of entry.
+func (g *Game) settingValue(entry int) string {
+	o := g.rulesOptions()
+	next := ""
+	if g.settingsFrom != StateMenu && g.pending.set {
+		next = " (next run)"
+	}
+	switch entry {
+	case settingSound:
+		return "sound: " + onOff(o.Sound && !g.sound.Muted())
+	case settingMusic:
+		return fmt.Sprintf("music volume: %d%%", int(g.sound.Volume().Music*100+0.5))
+	case settingEffects:
+		return fmt.Sprintf("effects volume: %d%%", int(g.sound.Volume().Effects*100+0.5))
+	case settingTheme:
+		return "colors: " + g.themeName()
+	case settingSmooth:
+		return "smooth movement: " + onOff(o.Smooth)
+	case settingWalls:
+		if o.Walls {
+			return "edges: solid walls" + next
+		}
+		return "edges: wrap around" + next
+	case settingBoard:
+		if i := g.boardPreset(); i >= 0 {
+			return fmt.Sprintf("board: %s, %dx%d", boardPresets[i].name, o.CellsX, o.CellsY)
+		}
+		return fmt.Sprintf("board: custom, %dx%d", o.CellsX, o.CellsY)
+	case settingSpeed:
+		if i := speedPresetOf(o.Speed); i >= 0 {
+			return "speed: " + speedPresets[i].name + next
+		}
+		return fmt.Sprintf("speed: custom, %g frames per step", o.Speed) + next
+	case settingControls:
+		return "controls..."
+	}
+	return ""
+}
+
+func (g *Game) drawSettings(canvas *ebiten.Image) {
+	lines := []string{"Settings"}
+	for i, entry := range g.settingEntries() {
+		prefix := "  "
+		if i == g.settingsEntry {
+			prefix = "> "
+		}
+		lines = append(lines, prefix+g.settingValue(entry))
+	}
+	lines = append(lines, "Up/Down choose, Left/Right change, Esc returns")
+	g.drawOverlay(canvas, lines...)
+}
+
+// saveVolume keeps the volume for the next start.
+func (g *Game) saveVolume() {
+	if g.options.VolumeFile == "" {
+		return
+	}
+	if err := g.sound.Volume().Save(g.options.VolumeFile); err != nil {
+		log.Printf("could not save volume: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package snake
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// shape is the outline an item is drawn in with Options.Shapes.
+type shape int
+
+const (
+	shapeSquare shape = iota
+	shapeCircle
+	shapeDiamond
+	shapeRing
+	shapeCross
+	shapeTriangle
+	shapePlus
+)
+
+// foodShapes are the shapes of the food types by name. Types missing
+// here are circles.
+var foodShapes = map[string]shape{
+	"bonus":  shapeDiamond,
+	"mega":   shapeRing,
+	"poison": shapeCross,
+}
+
+// inside reports whether (x, y), in fractions of the cell from its top
+// left corner, is inside the shape.
+func (s shape) inside(x, y float64) bool {
+	dx, dy := math.Abs(x-0.5), math.Abs(y-0.5)
+	switch s {
+	case shapeCircle:
+		return math.Hypot(dx, dy) <= 0.4
+	case shapeDiamond:
+		return dx+dy <= 0.45
+	case shapeRing:
+		d := math.Hypot(dx, dy)
+		return d >= 0.22 && d <= 0.45
+	case shapeCross:
+		return math.Abs(dx-dy) <= 0.12 && dx <= 0.4 && dy <= 0.4
+	case shapeTriangle:
+		return y >= 0.1 && y <= 0.9 && dx <= (y-0.1)*0.55
+	case shapePlus:
+		return (dx <= 0.12 || dy <= 0.12) && dx <= 0.42 && dy <= 0.42
+	}
+	return true
+}
+
+// itemTile creates the tile of an item of the board in c. With shapes
+// the item is drawn in s, otherwise it fills the cell.
+func (w *world) itemTile(s shape, c color.RGBA) *ebiten.Image {
+	if !w.shapes || s == shapeSquare {
+		tile, _ := ebiten.NewImage(w.cellW, w.cellH, w.filter)
+		tile.Fill(c)
+		return tile
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w.cellW, w.cellH))
+	drawSampled(img, 0, w.cellW, func(x, y float64) (color.RGBA, bool) {
+		return c, s.inside(x, y)
+	})
+	tile, _ := ebiten.NewImageFromImage(img, w.filter)
+	return tile
+}
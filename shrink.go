@@ -0,0 +1,57 @@
+package snake
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/audio"
+	"github.com/wongak/snake/game"
+)
+
+// warningAlpha is the opacity of the band of cells closing on the next
+// shrink of the arena.
+const warningAlpha = 0.45
+
+// drawShrinkWarning marks the ring of cells closing on the next shrink
+// of the arena with a blinking band.
+func (g *Game) drawShrinkWarning(canvas *ebiten.Image) {
+	ring, left, ok := g.sim.NextShrink()
+	if !ok || left > game.ShrinkWarning || left/15%2 == 1 {
+		return
+	}
+	w := g.w
+	cellsX, cellsY := g.sim.Size()
+	for x := ring; x <= cellsX-ring; x++ {
+		w.drawTile(canvas, w.warningTile, x, ring)
+		w.drawTile(canvas, w.warningTile, x, cellsY-ring)
+	}
+	for y := ring + 1; y < cellsY-ring; y++ {
+		w.drawTile(canvas, w.warningTile, ring, y)
+		w.drawTile(canvas, w.warningTile, cellsX-ring, y)
+	}
+}
+
+// drawShrinkCountdown counts down the seconds until the arena shrinks.
+func (g *Game) drawShrinkCountdown(canvas *ebiten.Image) {
+	_, left, ok := g.sim.NextShrink()
+	if !ok || left > game.ShrinkWarning || g.state != StatePlaying {
+		return
+	}
+	secs := (left + DefaultTickRate - 1) / DefaultTickRate
+	g.drawText(canvas, fmt.Sprintf("WALLS CLOSE IN %d", secs), g.w.cellW*2, g.hudRow(4), g.w.theme.Warning, 1)
+}
+
+// shrunk shakes the board as the arena closes in and shows the points
+// player one scored for surviving it, offline.
+func (g *Game) shrunk(e game.Event) {
+	g.sound.Play(audio.Milestone)
+	g.showMessage("the walls closed in")
+	if !g.options.Effects {
+		return
+	}
+	g.shake = shakeFrames / 2
+	if e.Points > 0 && g.net == nil {
+		h := g.sim.Snake().Head()
+		g.addPopup(h.X, h.Y, e.Points, 0)
+	}
+}
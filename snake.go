@@ -1,299 +1,314 @@
-package main
+// Package snake implements the snake game on top of ebiten. The rules
+// live in package game, this package reads the input, draws the board
+// and runs the menus around them. The game can be run standalone, see
+// cmd/snake, or embedded into another ebiten application by driving
+// Game.Update from its update function.
+package snake
 
 import (
 	"errors"
+	"image"
 	"image/color"
-	"log"
 	"math"
-	"math/rand"
-	"strconv"
+	// register PNG for background images
+	_ "image/png"
 
 	"github.com/hajimehoshi/ebiten"
-	"github.com/hajimehoshi/ebiten/text"
-	"golang.org/x/image/font/basicfont"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/wongak/snake/game"
 )
 
-const (
-	title = "snake"
-)
-
-var (
-	width  = 500
-	height = 400
-
-	cellsX = 60
-	cellsY = 40
-
-	initialLength = 3
-
-	speed float64 = 12.0
-)
-
-var (
-	bgColor     = color.RGBA{0x18, 0x29, 0x18, 0xff}
-	borderColor = color.RGBA{0x10, 0xa0, 0x10, 0xff}
-	snColor     = color.RGBA{0x20, 0xff, 0x20, 0xff}
-	foodColor   = color.RGBA{0xa0, 0xa0, 0x10, 0xff}
-	errEnd      = errors.New("end")
-	errLose     = errors.New("lose")
-	opts        = &ebiten.DrawImageOptions{}
-)
+// ErrEnd is returned by Game.Update when the player quits.
+var ErrEnd = errors.New("end")
 
 type world struct {
 	screenW, screenH int
 	cellsX, cellsY   int
 	cellW, cellH     int
-	tile             *ebiten.Image
-	foodTile         *ebiten.Image
+	// skins are the sprites of the snakes
+	snakeSkin     *skin
+	rivalSkin     *skin
+	ghostSkin     *skin
+	foodTiles     map[*game.FoodType]*ebiten.Image
+	forbiddenTile *ebiten.Image
+	obstacleTile  *ebiten.Image
+	borderTile    *ebiten.Image
+	// warningTile marks the cells closing on the next shrink
+	warningTile *ebiten.Image
+
+	// chunks are the borders and obstacles drawn into images of
+	// chunkCells by chunkCells cells, indexed by row and column of the
+	// chunk. chunkWalls are the obstacles of every chunk and chunkSig
+	// the obstacles they were drawn with.
+	chunks     map[int]*ebiten.Image
+	chunkWalls map[int][]game.Cell
+	chunkSig   chunkSignature
+
+	filter ebiten.Filter
+	theme  Theme
+	// background is drawn behind the board, nil for a plain fill
+	background *ebiten.Image
+	// segmented draws body cells with a margin
+	segmented bool
+	// shapes draws the items in shapes and the snakes in squares
+	shapes bool
+
+	// camera offset in pixels
+	camX, camY float64
+	// opts are reused for drawing the tiles
+	opts ebiten.DrawImageOptions
+}
 
-	borders *ebiten.Image
+// minCellSize is the smallest cell size in pixels a board is fit into
+// the screen with. Larger boards keep it and scroll.
+const minCellSize = 12
+
+// fitCellSize returns the largest square cell size fitting x by y cells
+// into w by h pixels, at least minCellSize.
+func fitCellSize(w, h, x, y int) int {
+	// cell size in pixels is at least width / (cells + 1),
+	// otherwise the last cell is outside of the screen
+	// + 2 for drawing a border on row/column 0
+	size := w / (x + 12)
+	if s := h / (y + 12); s < size {
+		size = s
+	}
+	if size < minCellSize {
+		return minCellSize
+	}
+	return size
 }
 
-func newWorld(w, h, x, y int) *world {
+func newWorld(w, h, x, y, size int, filter ebiten.Filter, theme Theme, shapes bool) *world {
 	world := &world{
+		theme:   theme,
+		shapes:  shapes,
 		screenW: w,
 		screenH: h,
 		cellsX:  x,
 		cellsY:  y,
-		// cell size in pixels is at least width / (cells + 1),
-		// otherwise the last cell is outside of the screen
-		// + 2 for drawing a border on row/column 0
-		cellW: w / (x + 12),
-		cellH: h / (y + 12),
+		filter:  filter,
 	}
-	cellSize := world.cellW
-	if world.cellH < cellSize {
-		cellSize = world.cellH
+	cellSize := fitCellSize(w, h, x, y)
+	if size > 0 {
+		cellSize = size
 	}
 	world.cellW, world.cellH = cellSize, cellSize
 
-	world.tile, _ = ebiten.NewImage(world.cellW, world.cellH, ebiten.FilterNearest)
-	world.tile.Fill(snColor)
-	world.foodTile, _ = ebiten.NewImage(world.cellW, world.cellH, ebiten.FilterNearest)
-	world.foodTile.Fill(foodColor)
-
-	world.initBorders()
+	world.snakeSkin = newSkin(cellSize, filter, theme.Snake, theme.Head, theme.Background, shapes)
+	world.rivalSkin = newSkin(cellSize, filter, theme.Rival, theme.RivalHead, theme.Background, shapes)
+	ghost := translucent(theme.Snake, ghostAlpha)
+	world.ghostSkin = newSkin(cellSize, filter, ghost, ghost, translucent(theme.Background, ghostAlpha), shapes)
+	world.foodTiles = make(map[*game.FoodType]*ebiten.Image, len(game.FoodTypes))
+	for _, t := range game.FoodTypes {
+		c := world.foodColor(t)
+		s, ok := foodShapes[t.Name]
+		if !ok {
+			s = shapeCircle
+		}
+		world.foodTiles[t] = world.itemTile(s, c)
+	}
+	world.forbiddenTile = world.itemTile(shapeTriangle, theme.Forbidden)
+	world.obstacleTile = world.itemTile(shapeSquare, theme.Obstacle)
+	world.borderTile = world.itemTile(shapeSquare, theme.Border)
+	world.warningTile = world.itemTile(shapeSquare, translucent(theme.Warning, warningAlpha))
 	return world
 }
 
-func (w *world) initBorders() {
-	w.borders, _ = ebiten.NewImage(w.screenW, w.screenH, ebiten.FilterNearest)
-	hor, _ := ebiten.NewImage(w.cellW*(w.cellsX+2), w.cellH, ebiten.FilterNearest)
-	hor.Fill(borderColor)
-	w.borders.DrawImage(hor, opts)
-	opts.GeoM.Reset()
-	opts.GeoM.Translate(0, float64(w.cellH*(w.cellsY+2)))
-	w.borders.DrawImage(hor, opts)
-	vert, _ := ebiten.NewImage(w.cellW, w.cellH*(w.cellsY+3), ebiten.FilterNearest)
-	vert.Fill(borderColor)
-	opts.GeoM.Reset()
-	w.borders.DrawImage(vert, opts)
-	opts.GeoM.Translate(float64(w.cellW*(w.cellsX+2)), 0)
-	w.borders.DrawImage(vert, opts)
+// margin returns the space left of and above a board centered in the
+// window, 0 for boards scrolling with the camera.
+func (w *world) margin() (int, int) {
+	return int(math.Max(0, -w.camX)), int(math.Max(0, -w.camY))
 }
 
-func (w *world) draw(canvas *ebiten.Image) {
-	canvas.DrawImage(w.borders, &ebiten.DrawImageOptions{})
+// scrolls reports whether the board is larger than the screen, so the
+// camera scrolls over it.
+func (w *world) scrolls() bool {
+	bw, bh := w.boardSize()
+	return bw > w.screenW || bh+hudCells*w.cellH > w.screenH
 }
 
-type node struct {
-	child, parent *node
-	x, y          int
+// boardSize returns the size of the board including the borders in pixels.
+func (w *world) boardSize() (int, int) {
+	return w.cellW * (w.cellsX + 3), w.cellH * (w.cellsY + 3)
 }
 
-func (n *node) draw(w *world, canvas *ebiten.Image) {
-	opts.GeoM.Reset()
-	opts.GeoM.Translate(float64(w.cellW*(n.x+1)), float64(w.cellH*(n.y+1)))
-	canvas.DrawImage(w.tile, opts)
-	if n.child != nil {
-		n.child.draw(w, canvas)
-	}
+// cellToPixel returns the pixel position of the top left corner of a cell
+// on the board. Row and column 0 of the board are taken by the border,
+// so cell (0, 0) is drawn at (cellW, cellH).
+func (w *world) cellToPixel(x, y int) (float64, float64) {
+	return float64(w.cellW * (x + 1)), float64(w.cellH * (y + 1))
 }
 
-func (n *node) step(w *world) {
-	if n.child != nil {
-		n.child.step(w)
-	}
-	n.x = n.parent.x
-	n.y = n.parent.y
-	if n.child == nil && grow > 0 {
-		curr := n
-		for ; grow > 0; grow-- {
-			curr.child = &node{parent: curr, x: curr.x, y: curr.y}
-			curr = curr.child
-		}
-	}
+// hudCells is the number of rows of cells below the board kept for
+// the HUD.
+const hudCells = 4
+
+// follow centers the camera on the given cell. The camera is clamped
+// at the board edges, boards fitting into the window do not scroll but
+// are centered, together with the HUD below them.
+func (w *world) follow(x, y int) {
+	w.lookAt(float64(x), float64(y))
 }
 
-func (n *node) collided(x, y int) bool {
-	if x == n.x && y == n.y {
-		return true
-	}
-	if n.child != nil {
-		return n.child.collided(x, y)
-	}
-	return false
+// glide centers the camera on the head of s at progress t of the step,
+// so a scrolling board moves along with the head instead of a cell at
+// a time.
+func (w *world) glide(s *game.Snake, t float64) {
+	px, py := w.segmentToPixel(s, 0, t)
+	w.lookAt(px/float64(w.cellW)-1, py/float64(w.cellH)-1)
 }
 
-type head struct {
-	*node
-	direction int
+// lookAt centers the camera on the point x, y in cells, like follow
+// for points between cells.
+func (w *world) lookAt(x, y float64) {
+	bw, bh := w.boardSize()
+	px, py := float64(w.cellW)*(x+1), float64(w.cellH)*(y+1)
+	w.camX = clampCam(px+float64(w.cellW)/2-float64(w.screenW)/2, bw, w.screenW)
+	w.camY = clampCam(py+float64(w.cellH)/2-float64(w.screenH)/2, bh+hudCells*w.cellH, w.screenH)
 }
 
-func (h *head) move(w *world, direction int) {
-	if h.child != nil {
-		h.child.step(w)
-	}
-	switch direction % 4 {
-	case 0:
-		h.x += 1
-	case 1:
-		h.y += 1
-	case 2:
-		h.x -= 1
-	case 3:
-		h.y -= 1
+func clampCam(c float64, board, screen int) float64 {
+	if board <= screen {
+		return -float64((screen - board) / 2)
 	}
-	if h.x < 0 {
-		h.x = w.cellsX
+	if c < 0 {
+		return 0
 	}
-	if h.x > w.cellsX {
-		h.x = 0
+	if max := float64(board - screen); c > max {
+		return max
 	}
-	if h.y < 0 {
-		h.y = w.cellsY
+	return c
+}
+
+// loadBackground loads a background image from a PNG file and scales
+// it to cover the playfield.
+func (w *world) loadBackground(path string) error {
+	file, err := ebitenutil.OpenFile(path)
+	if err != nil {
+		return err
 	}
-	if h.y > w.cellsY {
-		h.y = 0
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return err
 	}
+	w.background, err = ebiten.NewImageFromImage(img, w.filter)
+	return err
 }
 
-func (h *head) alive() bool {
-	if h.child == nil {
-		return true
+func (w *world) draw(canvas *ebiten.Image) {
+	if w.background != nil {
+		bw, bh := w.background.Size()
+		x, y := w.cellToPixel(0, 0)
+		w.opts.GeoM.Reset()
+		w.opts.GeoM.Scale(float64(w.cellW*(w.cellsX+1))/float64(bw), float64(w.cellH*(w.cellsY+1))/float64(bh))
+		w.opts.GeoM.Translate(x-w.camX, y-w.camY)
+		canvas.DrawImage(w.background, &w.opts)
 	}
-	return !h.child.collided(h.x, h.y)
 }
 
-func initSnake(w *world, initialLength int) *head {
-	head := &head{
-		node: &node{
-			x: w.cellsX / 2,
-			y: w.cellsY / 2,
-		},
-	}
-	currNode := head.node
-	for i := 1; i < initialLength; i++ {
-		node := &node{
-			x: currNode.x - 1,
-			y: currNode.y,
-		}
-		currNode.child = node
-		node.parent = currNode
-		currNode = node
+// segmentMargin is the margin around body cells in a segmented snake
+// as a fraction of the cell size.
+const segmentMargin = 0.1
+
+// drawTile draws tile on the cell (x, y) of the board, unless the cell
+// is off the screen.
+func (w *world) drawTile(canvas, tile *ebiten.Image, x, y int) {
+	px, py := w.cellToPixel(x, y)
+	if !w.onScreen(px, py) {
+		return
 	}
-	return head
+	w.opts.GeoM.Reset()
+	w.opts.GeoM.Translate(px-w.camX, py-w.camY)
+	canvas.DrawImage(tile, &w.opts)
 }
 
-type food struct {
-	x, y int
+// onScreen reports whether a cell with its top left corner at the
+// pixel (px, py) of the board is on the screen.
+func (w *world) onScreen(px, py float64) bool {
+	x, y := px-w.camX, py-w.camY
+	return x > -float64(w.cellW) && y > -float64(w.cellH) && x < float64(w.screenW) && y < float64(w.screenH)
 }
 
-func (f *food) respawn(w *world) {
-	var x, y int
-	for {
-		x = rand.Intn(w.cellsX)
-		y = rand.Intn(w.cellsY)
-		if h.collided(x, y) {
-			continue
-		}
-		break
+// segmentToPixel returns the pixel position of the i-th segment of s
+// at progress t of the step, between the cell it was on before the
+// step and its cell. Segments which wrapped around the board edge jump
+// to their cell.
+func (w *world) segmentToPixel(s *game.Snake, i int, t float64) (float64, float64) {
+	c := s.At(i)
+	x, y := w.cellToPixel(c.X, c.Y)
+	if t >= 1 {
+		return x, y
+	}
+	p := s.Previous(i)
+	if abs(p.X-c.X)+abs(p.Y-c.Y) != 1 {
+		return x, y
 	}
-	f.x = x
-	f.y = y
+	px, py := w.cellToPixel(p.X, p.Y)
+	return px + (x-px)*t, py + (y-py)*t
 }
 
-func (f *food) draw(w *world, canvas *ebiten.Image) {
-	opts.GeoM.Reset()
-	opts.GeoM.Translate(float64(w.cellW*(f.x+1)), float64(w.cellH*(f.y+1)))
-	canvas.DrawImage(w.foodTile, opts)
+// drawSnake draws s with the pieces of sk at progress t of the step,
+// see segmentToPixel. Segments on their way to the next cell are drawn
+// straight along the way they move, bends are drawn once they arrived.
+func (w *world) drawSnake(canvas *ebiten.Image, s *game.Snake, sk *skin, t float64) {
+	n := s.Len()
+	// from the tail, so the head is on top
+	for i := n - 1; i >= 0; i-- {
+		x, y := w.segmentToPixel(s, i, t)
+		c, prev := s.At(i), s.Previous(i)
+		moving := t < 1 && prev != c
+		switch {
+		case i == 0:
+			w.drawPiece(canvas, sk, pieceHead, s.Direction(), x, y)
+		case i == n-1 && moving:
+			w.drawPiece(canvas, sk, pieceTail, towards(prev, c), x, y)
+		case i == n-1:
+			w.drawPiece(canvas, sk, pieceTail, towards(c, s.At(i-1)), x, y)
+		case moving:
+			w.drawPiece(canvas, sk, pieceBody, towards(prev, c)%2, x, y)
+		default:
+			piece, rotation := bend(towards(c, s.At(i-1)), towards(c, s.At(i+1)))
+			w.drawPiece(canvas, sk, piece, rotation, x, y)
+		}
+	}
 }
 
-func drawPoints(w *world, canvas *ebiten.Image) {
-	text.Draw(canvas, strconv.FormatInt(points, 10), basicfont.Face7x13, w.cellW, w.cellH*(w.cellsY+6), snColor)
+// foodColors are the tile colors of the food types by name. Types
+// missing here are drawn in the theme's food color.
+var foodColors = map[string]color.RGBA{
+	"bonus":  {0xff, 0xe0, 0x40, 0xff},
+	"mega":   {0xff, 0x70, 0x30, 0xff},
+	"poison": {0x80, 0x20, 0xa0, 0xff},
 }
 
-var (
-	w      *world
-	h      *head
-	f      *food
-	grow   int = 1
-	moving bool
-	frame  int64
-	points int64
-)
-
-func main() {
-	w = newWorld(width, height, cellsX, cellsY)
-	h = initSnake(w, initialLength)
-	f = &food{}
-	f.respawn(w)
-	if err := ebiten.Run(update, width, height, 2, title); err != nil {
-		if err == errEnd {
-			return
-		}
-		log.Fatal(err)
+// foodColor returns the color food of type t is drawn in.
+func (w *world) foodColor(t *game.FoodType) color.RGBA {
+	if c, ok := foodColors[t.Name]; ok {
+		return c
 	}
+	return w.theme.Food
 }
 
-func update(screen *ebiten.Image) error {
-	frame++
-	if ebiten.IsRunningSlowly() {
-		// frame skip
-		return nil
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return errEnd
-	}
-	if (ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW)) &&
-		h.direction%4 != 1 {
-		h.direction = 3
-	}
-	if (ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS)) &&
-		h.direction%4 != 3 {
-		h.direction = 1
-	}
-	if (ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA)) &&
-		h.direction%4 != 0 {
-		h.direction = 2
-	}
-	if (ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD)) &&
-		h.direction%4 != 2 {
-		h.direction = 0
+func (w *world) drawFood(canvas *ebiten.Image, f *game.Food) {
+	// blink before expiring
+	if f.Life > 0 && f.Life < 2*60 && f.Life/8%2 == 0 {
+		return
 	}
-	currSpeed := speed - (float64(points) / 10000.0)
+	w.drawFoodTile(canvas, f, w.foodTiles[f.Kind])
+}
 
-	if frame%int64(currSpeed) == 0 {
-		h.move(w, h.direction)
-		if !h.alive() {
-			return errLose
-		}
-		points += 10
-	}
-	// eat
-	if h.node.x == f.x && h.node.y == f.y {
-		points += 1000
-		grow = int(math.Log10(float64(points)))
-		f.respawn(w)
+func (w *world) drawFoodTile(canvas *ebiten.Image, f *game.Food, tile *ebiten.Image) {
+	if f.X < 0 {
+		// no free cell left
+		return
 	}
+	w.drawTile(canvas, tile, f.X, f.Y)
+}
 
-	screen.Fill(bgColor)
-	w.draw(screen)
-	h.draw(w, screen)
-	if f != nil {
-		f.draw(w, screen)
+func (g *Game) drawChaos(canvas *ebiten.Image) {
+	if g.sim.Reversed() {
+		g.drawText(canvas, "CONTROLS REVERSED!", g.w.cellW*2, g.hudRow(3), g.w.theme.Warning, 1)
 	}
-	drawPoints(w, screen)
-
-	return nil
 }
@@ -0,0 +1,29 @@
+package snake
+
+import "testing"
+
+func TestCellToPixel(t *testing.T) {
+	tests := []struct {
+		name         string
+		cellW, cellH int
+		x, y         int
+		px, py       float64
+	}{
+		{"origin", 16, 16, 0, 0, 16, 16},
+		{"right edge", 16, 16, 59, 0, 960, 16},
+		{"bottom edge", 16, 16, 0, 39, 16, 640},
+		{"far corner", 16, 16, 59, 39, 960, 640},
+		{"small cells", 12, 12, 10, 5, 132, 72},
+		{"large cells", 32, 32, 10, 5, 352, 192},
+		{"wide cells", 20, 10, 3, 3, 80, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &world{cellsX: 59, cellsY: 39, cellW: tt.cellW, cellH: tt.cellH}
+			px, py := w.cellToPixel(tt.x, tt.y)
+			if px != tt.px || py != tt.py {
+				t.Errorf("cell (%d, %d) at (%v, %v), want (%v, %v)", tt.x, tt.y, px, py, tt.px, tt.py)
+			}
+		})
+	}
+}
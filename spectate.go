@@ -0,0 +1,84 @@
+package snake
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// cameraSpeed is the number of cells per second the camera of a
+// spectator moves by.
+const cameraSpeed = 20.0
+
+// spectating reports whether the game watches an online match.
+func (g *Game) spectating() bool {
+	return g.net != nil && g.netPlayer < 0
+}
+
+// watch lets the camera of a spectator follow player, or move freely
+// for -1.
+func (g *Game) watch(player int) {
+	g.watchFollow = player
+}
+
+// updateCamera moves the camera of a spectator: the arrow keys move it
+// freely, 1 and 2 follow a player unless typing in the chat.
+func (g *Game) updateCamera() {
+	switch {
+	case g.chatOpen:
+		// the keys type the chat message
+	case inpututil.IsKeyJustPressed(ebiten.Key1):
+		g.watch(0)
+	case inpututil.IsKeyJustPressed(ebiten.Key2):
+		g.watch(1)
+	}
+	var dx, dy float64
+	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		dx--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyRight) {
+		dx++
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyUp) {
+		dy--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyDown) {
+		dy++
+	}
+	if dx != 0 || dy != 0 {
+		g.watch(-1)
+		step := cameraSpeed / DefaultTickRate
+		g.watchX += dx * step
+		g.watchY += dy * step
+	}
+	if g.watchFollow >= 0 {
+		if s, _ := g.shownSnake(g.watchFollow); s != nil {
+			h := s.Head()
+			g.watchX, g.watchY = float64(h.X), float64(h.Y)
+		}
+	}
+	cellsX, cellsY := g.sim.Size()
+	g.watchX = math.Min(math.Max(g.watchX, 0), float64(cellsX-1))
+	g.watchY = math.Min(math.Max(g.watchY, 0), float64(cellsY-1))
+	g.w.lookAt(g.watchX, g.watchY)
+}
+
+// drawSpectating tells spectators whom they watch and how to move the
+// camera.
+func (g *Game) drawSpectating(canvas *ebiten.Image) {
+	if !g.spectating() || g.state != StatePlaying {
+		return
+	}
+	code := ""
+	if g.room != nil {
+		code = " " + g.room.Code
+	}
+	following := "free camera"
+	if g.watchFollow >= 0 {
+		following = fmt.Sprintf("following player %d", g.watchFollow+1)
+	}
+	g.drawText(canvas, fmt.Sprintf("watching room%s, %s - arrows move, 1/2 follow, Esc leaves", code, following),
+		g.w.cellW*2, g.hudRow(2), g.w.theme.HUD, 1)
+}
@@ -0,0 +1,162 @@
+package snake
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// The pieces of a snake, in the order of the sprite sheet.
+const (
+	pieceHead = iota
+	pieceBody
+	pieceCorner
+	pieceTail
+	pieces
+)
+
+// skin is the sprite sheet of the pieces of a snake. The pieces are
+// drawn for a snake moving right: the head faces right, the body runs
+// from the left to the right edge, the corner joins the left and the
+// bottom edge and the tail tapers off to the left. They are rotated in
+// quarter turns for the other directions.
+type skin struct {
+	sheet *ebiten.Image
+	size  int
+}
+
+// spriteSamples is the number of samples per pixel along each axis
+// the pieces are drawn with, smoothing their edges.
+const spriteSamples = 4
+
+// newSkin draws the pieces of a snake into a sprite sheet of cells of
+// size pixels. The head is drawn in head with eyes in eye, the other
+// pieces in body. With square all pieces are squares.
+func newSkin(size int, filter ebiten.Filter, body, head, eye color.RGBA, square bool) *skin {
+	img := image.NewRGBA(image.Rect(0, 0, pieces*size, size))
+	for p := 0; p < pieces; p++ {
+		p := p
+		drawSampled(img, p*size, size, func(x, y float64) (color.RGBA, bool) {
+			return pieceAt(p, x, y, square, body, head, eye)
+		})
+	}
+	sheet, _ := ebiten.NewImageFromImage(img, filter)
+	return &skin{sheet: sheet, size: size}
+}
+
+// drawSampled draws a cell of size pixels into img at x0, with the
+// color at of each point of the cell, in fractions of the cell from its
+// top left corner. Pixels are averaged over spriteSamples squared
+// points.
+func drawSampled(img *image.RGBA, x0, size int, at func(x, y float64) (color.RGBA, bool)) {
+	const n = spriteSamples * spriteSamples
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			var r, g, b, a int
+			for s := 0; s < n; s++ {
+				x := (float64(px) + (float64(s%spriteSamples)+0.5)/spriteSamples) / float64(size)
+				y := (float64(py) + (float64(s/spriteSamples)+0.5)/spriteSamples) / float64(size)
+				if c, ok := at(x, y); ok {
+					r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+				}
+			}
+			img.SetRGBA(x0+px, py, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+}
+
+// pieceMargin is the gap between the body and the cell edges as a
+// fraction of the cell size.
+const pieceMargin = 0.15
+
+// pieceAt returns the color of a piece at (x, y), in fractions of the
+// cell from its top left corner, and false outside of the piece.
+func pieceAt(piece int, x, y float64, square bool, body, head, eye color.RGBA) (color.RGBA, bool) {
+	// a little wider than the body
+	r := 0.5 - pieceMargin/2
+	inSquare := math.Abs(x-0.5) <= r && math.Abs(y-0.5) <= r
+	if square && piece != pieceHead {
+		if inSquare {
+			return body, true
+		}
+		return color.RGBA{}, false
+	}
+	switch piece {
+	case pieceHead:
+		for _, ey := range [...]float64{0.3, 0.7} {
+			if math.Hypot(x-0.62, y-ey) < 0.11 {
+				return eye, true
+			}
+		}
+		// rounded at the front unless square
+		if square && inSquare || x <= 0.5 && math.Abs(y-0.5) <= r || math.Hypot(x-0.5, y-0.5) <= r {
+			return head, true
+		}
+	case pieceBody:
+		if math.Abs(y-0.5) <= 0.5-pieceMargin {
+			return body, true
+		}
+	case pieceCorner:
+		if d := math.Hypot(x, y-1); d >= pieceMargin && d <= 1-pieceMargin {
+			return body, true
+		}
+	case pieceTail:
+		if math.Abs(y-0.5) <= (0.5-pieceMargin)*(0.2+0.8*x) {
+			return body, true
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// towards returns the direction from cell a to its neighbor b, across
+// the board edge if they are on opposite edges.
+func towards(a, b game.Cell) int {
+	switch dx, dy := b.X-a.X, b.Y-a.Y; {
+	case dx == 1 || dx < -1:
+		return 0
+	case dy == 1 || dy < -1:
+		return 1
+	case dx == -1 || dx > 1:
+		return 2
+	}
+	return 3
+}
+
+// bend returns the piece joining the directions front and back and its
+// rotation.
+func bend(front, back int) (piece, rotation int) {
+	if front == (back+2)%4 || front == back {
+		return pieceBody, front % 2
+	}
+	// the corner joins left and down, rotations add to both
+	for r := 0; r < 4; r++ {
+		a, b := (2+r)%4, (1+r)%4
+		if a == front && b == back || a == back && b == front {
+			return pieceCorner, r
+		}
+	}
+	return pieceBody, front % 2
+}
+
+// drawPiece draws a piece of sk rotated by quarter turns clockwise into
+// the cell with its top left corner at (x, y) on the board.
+func (w *world) drawPiece(canvas *ebiten.Image, sk *skin, piece, rotation int, x, y float64) {
+	if !w.onScreen(x, y) {
+		return
+	}
+	half := float64(sk.size) / 2
+	r := image.Rect(piece*sk.size, 0, (piece+1)*sk.size, sk.size)
+	w.opts.SourceRect = &r
+	w.opts.GeoM.Reset()
+	w.opts.GeoM.Translate(-half, -half)
+	w.opts.GeoM.Rotate(float64(rotation) * math.Pi / 2)
+	if w.segmented {
+		w.opts.GeoM.Scale(1-2*segmentMargin, 1-2*segmentMargin)
+	}
+	w.opts.GeoM.Translate(x+half-w.camX, y+half-w.camY)
+	canvas.DrawImage(sk.sheet, &w.opts)
+	w.opts.SourceRect = nil
+}
@@ -0,0 +1,190 @@
+package snake
+
+import "image/color"
+
+// Theme are the colors the game is drawn in.
+type Theme struct {
+	// Name is the name of a built-in theme, empty for custom colors.
+	Name       string
+	Background color.RGBA
+	Border     color.RGBA
+	Snake      color.RGBA
+	Head       color.RGBA
+	Rival      color.RGBA
+	RivalHead  color.RGBA
+	Food       color.RGBA
+	Forbidden  color.RGBA
+	Obstacle   color.RGBA
+	Warning    color.RGBA
+	HUD        color.RGBA
+	// Overlay dims the board behind menus, with premultiplied alpha.
+	// The HUD color has to be readable on it.
+	Overlay color.RGBA
+}
+
+// DefaultTheme is the classic green theme.
+var DefaultTheme = Theme{
+	Name:       "classic",
+	Background: color.RGBA{0x18, 0x29, 0x18, 0xff},
+	Border:     color.RGBA{0x10, 0xa0, 0x10, 0xff},
+	Snake:      color.RGBA{0x20, 0xff, 0x20, 0xff},
+	Head:       color.RGBA{0x90, 0xff, 0x90, 0xff},
+	Rival:      color.RGBA{0x30, 0x90, 0xff, 0xff},
+	RivalHead:  color.RGBA{0x90, 0xc8, 0xff, 0xff},
+	Food:       color.RGBA{0xa0, 0xa0, 0x10, 0xff},
+	Forbidden:  color.RGBA{0xe0, 0x20, 0xe0, 0xff},
+	Obstacle:   color.RGBA{0x70, 0x70, 0x70, 0xff},
+	Warning:    color.RGBA{0xff, 0x40, 0x40, 0xff},
+	HUD:        color.RGBA{0xe0, 0xe0, 0xe0, 0xff},
+	Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xa0},
+}
+
+// Themes are the built-in themes, switched through on the menu.
+var Themes = []Theme{
+	DefaultTheme,
+	{
+		Name:       "dark",
+		Background: color.RGBA{0x0c, 0x0c, 0x10, 0xff},
+		Border:     color.RGBA{0x3a, 0x3a, 0x48, 0xff},
+		Snake:      color.RGBA{0x5c, 0xc8, 0x8c, 0xff},
+		Head:       color.RGBA{0xa8, 0xf0, 0xc8, 0xff},
+		Rival:      color.RGBA{0xc0, 0x70, 0xe0, 0xff},
+		RivalHead:  color.RGBA{0xe0, 0xb8, 0xf4, 0xff},
+		Food:       color.RGBA{0xf0, 0xa0, 0x30, 0xff},
+		Forbidden:  color.RGBA{0xe0, 0x30, 0x60, 0xff},
+		Obstacle:   color.RGBA{0x50, 0x50, 0x5c, 0xff},
+		Warning:    color.RGBA{0xff, 0x50, 0x50, 0xff},
+		HUD:        color.RGBA{0xc8, 0xc8, 0xd0, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xb0},
+	},
+	{
+		Name:       "light",
+		Background: color.RGBA{0xf4, 0xf1, 0xe8, 0xff},
+		Border:     color.RGBA{0x8a, 0x84, 0x78, 0xff},
+		Snake:      color.RGBA{0x2e, 0x8b, 0x57, 0xff},
+		Head:       color.RGBA{0x1b, 0x5e, 0x3a, 0xff},
+		Rival:      color.RGBA{0x2a, 0x6f, 0xc9, 0xff},
+		RivalHead:  color.RGBA{0x17, 0x45, 0x85, 0xff},
+		Food:       color.RGBA{0xd9, 0x48, 0x1e, 0xff},
+		Forbidden:  color.RGBA{0x8e, 0x24, 0xaa, 0xff},
+		Obstacle:   color.RGBA{0x6e, 0x6a, 0x62, 0xff},
+		Warning:    color.RGBA{0xc6, 0x28, 0x28, 0xff},
+		HUD:        color.RGBA{0x30, 0x30, 0x30, 0xff},
+		Overlay:    color.RGBA{0xc0, 0xc0, 0xc0, 0xc0},
+	},
+	{
+		Name:       "contrast",
+		Background: color.RGBA{0x00, 0x00, 0x00, 0xff},
+		Border:     color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Snake:      color.RGBA{0xff, 0xff, 0x00, 0xff},
+		Head:       color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Rival:      color.RGBA{0x00, 0xff, 0xff, 0xff},
+		RivalHead:  color.RGBA{0xb0, 0xff, 0xff, 0xff},
+		Food:       color.RGBA{0xff, 0x00, 0xff, 0xff},
+		Forbidden:  color.RGBA{0xff, 0x20, 0x20, 0xff},
+		Obstacle:   color.RGBA{0x90, 0x90, 0x90, 0xff},
+		Warning:    color.RGBA{0xff, 0x30, 0x30, 0xff},
+		HUD:        color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xd0},
+	},
+	{
+		// the Okabe-Ito colors, told apart with any color vision
+		Name:       "colorblind",
+		Background: color.RGBA{0x1a, 0x1a, 0x1a, 0xff},
+		Border:     color.RGBA{0x99, 0x99, 0x99, 0xff},
+		Snake:      color.RGBA{0x00, 0x72, 0xb2, 0xff},
+		Head:       color.RGBA{0x56, 0xb4, 0xe9, 0xff},
+		Rival:      color.RGBA{0xcc, 0x79, 0xa7, 0xff},
+		RivalHead:  color.RGBA{0xe6, 0xb8, 0xd2, 0xff},
+		Food:       color.RGBA{0xe6, 0x9f, 0x00, 0xff},
+		Forbidden:  color.RGBA{0xd5, 0x5e, 0x00, 0xff},
+		Obstacle:   color.RGBA{0x66, 0x66, 0x66, 0xff},
+		Warning:    color.RGBA{0xf0, 0xe4, 0x42, 0xff},
+		HUD:        color.RGBA{0xf0, 0xf0, 0xf0, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xb0},
+	},
+	{
+		// blue against yellow and orange, no red against green
+		Name:       "deuteranopia",
+		Background: color.RGBA{0x14, 0x18, 0x24, 0xff},
+		Border:     color.RGBA{0x70, 0x78, 0x90, 0xff},
+		Snake:      color.RGBA{0x2f, 0x80, 0xed, 0xff},
+		Head:       color.RGBA{0x9c, 0xc8, 0xff, 0xff},
+		Rival:      color.RGBA{0xf5, 0xd0, 0x20, 0xff},
+		RivalHead:  color.RGBA{0xff, 0xf0, 0xa0, 0xff},
+		Food:       color.RGBA{0xff, 0x8c, 0x00, 0xff},
+		Forbidden:  color.RGBA{0xf0, 0xf0, 0xf0, 0xff},
+		Obstacle:   color.RGBA{0x60, 0x60, 0x68, 0xff},
+		Warning:    color.RGBA{0xff, 0xb0, 0x00, 0xff},
+		HUD:        color.RGBA{0xe8, 0xe8, 0xe8, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xb0},
+	},
+	{
+		// like deuteranopia, without dark reds which look black
+		Name:       "protanopia",
+		Background: color.RGBA{0x14, 0x18, 0x24, 0xff},
+		Border:     color.RGBA{0x70, 0x78, 0x90, 0xff},
+		Snake:      color.RGBA{0x00, 0x9e, 0xff, 0xff},
+		Head:       color.RGBA{0xa0, 0xd8, 0xff, 0xff},
+		Rival:      color.RGBA{0xf0, 0xe4, 0x42, 0xff},
+		RivalHead:  color.RGBA{0xff, 0xf6, 0xb0, 0xff},
+		Food:       color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Forbidden:  color.RGBA{0xe6, 0x9f, 0x00, 0xff},
+		Obstacle:   color.RGBA{0x60, 0x60, 0x68, 0xff},
+		Warning:    color.RGBA{0xff, 0xe0, 0x40, 0xff},
+		HUD:        color.RGBA{0xe8, 0xe8, 0xe8, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xb0},
+	},
+	{
+		// red against teal, no blue against green or yellow
+		Name:       "tritanopia",
+		Background: color.RGBA{0x18, 0x18, 0x18, 0xff},
+		Border:     color.RGBA{0x80, 0x80, 0x80, 0xff},
+		Snake:      color.RGBA{0xe8, 0x30, 0x40, 0xff},
+		Head:       color.RGBA{0xff, 0xa0, 0xa8, 0xff},
+		Rival:      color.RGBA{0x00, 0xa8, 0xa8, 0xff},
+		RivalHead:  color.RGBA{0x90, 0xe0, 0xe0, 0xff},
+		Food:       color.RGBA{0xf8, 0xf8, 0xf8, 0xff},
+		Forbidden:  color.RGBA{0xff, 0x70, 0xc0, 0xff},
+		Obstacle:   color.RGBA{0x70, 0x70, 0x70, 0xff},
+		Warning:    color.RGBA{0xff, 0x40, 0x60, 0xff},
+		HUD:        color.RGBA{0xf0, 0xf0, 0xf0, 0xff},
+		Overlay:    color.RGBA{0x00, 0x00, 0x00, 0xb0},
+	},
+}
+
+// LookupTheme returns the built-in theme called name.
+func LookupTheme(name string) (Theme, bool) {
+	for _, t := range Themes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, len(Themes))
+	for i, t := range Themes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// nextTheme returns the built-in theme after t, the first one after a
+// custom theme.
+func nextTheme(t Theme) Theme {
+	return shiftTheme(t, 1)
+}
+
+// shiftTheme returns the built-in theme n places after t, or before it
+// for negative n, the first one after a custom theme.
+func shiftTheme(t Theme, n int) Theme {
+	for i, b := range Themes {
+		if b.Name == t.Name {
+			return Themes[((i+n)%len(Themes)+len(Themes))%len(Themes)]
+		}
+	}
+	return Themes[0]
+}
@@ -0,0 +1,97 @@
+package snake
+
+import (
+	"time"
+
+	"github.com/wongak/snake/game"
+)
+
+// DefaultTickRate is the number of ticks per second the rules advance
+// by unless Options.TickRate is set. Ebiten updates as often, so at the
+// default rate every update runs one tick.
+const DefaultTickRate = 60
+
+// maxLag is the time an update catches up at most. Longer stalls, like
+// a dragged window, are dropped instead of fast-forwarding the run.
+const maxLag = 250 * time.Millisecond
+
+// clock decouples the ticks of the rules from the updates. It
+// accumulates the time passed since the last update and hands it out
+// in ticks of a fixed length, so the snake moves at the same speed
+// however often ebiten updates or how many updates it skips.
+type clock struct {
+	tick time.Duration
+	last time.Time
+	lag  time.Duration
+}
+
+func newClock(rate int) clock {
+	if rate < 1 {
+		rate = DefaultTickRate
+	}
+	return clock{tick: time.Second / time.Duration(rate)}
+}
+
+// rate returns the number of ticks per second.
+func (c *clock) rate() float64 {
+	return float64(time.Second) / float64(c.tick)
+}
+
+// ticks returns the number of ticks due at now.
+func (c *clock) ticks(now time.Time) int {
+	if c.last.IsZero() {
+		// the first update after a stop runs a single tick
+		c.last = now.Add(-c.tick)
+	}
+	c.lag += now.Sub(c.last)
+	c.last = now
+	if c.lag > maxLag {
+		c.lag = maxLag
+	}
+	n := int(c.lag / c.tick)
+	c.lag -= time.Duration(n) * c.tick
+	return n
+}
+
+// fraction returns the fraction of the next tick passed.
+func (c *clock) fraction() float64 {
+	return float64(c.lag) / float64(c.tick)
+}
+
+// stop stops the clock while the run is not advancing, so the time of
+// a pause is not caught up once it continues.
+func (c *clock) stop() {
+	c.last = time.Time{}
+	c.lag = 0
+}
+
+// advance runs the ticks of the run due since the last update. It
+// stops early once the run left state, like after the snake died.
+func (g *Game) advance() {
+	state := g.state
+	ticks, steps := 0, 0
+	n := g.clock.ticks(time.Now())
+	if g.replay != nil {
+		n = g.scrub.ticks(n)
+	}
+	for ; n > 0 && g.state == state; n-- {
+		g.update()
+		ticks++
+		if g.sim.StepProgress(0) == 0 {
+			steps++
+		}
+	}
+	g.debug.countTicks(ticks, steps)
+}
+
+// progress returns how far sim is into its step for drawing the snakes
+// in between cells, 1 draws them on their cells.
+func (g *Game) progress(sim *game.Game) float64 {
+	if !g.options.Smooth {
+		return 1
+	}
+	if g.replay != nil && g.scrub.paused {
+		return sim.StepProgress(0)
+	}
+	return sim.StepProgress(g.clock.fraction())
+}
@@ -0,0 +1,128 @@
+package snake
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+const (
+	// swipeFraction is the share of the screen height a finger has to
+	// travel to count as a swipe.
+	swipeFraction = 0.05
+	// touchButtonFraction is the size of an on-screen arrow button as
+	// a share of the screen height.
+	touchButtonFraction = 0.1
+)
+
+// touchButtonColor is the color of the on-screen arrow buttons.
+var touchButtonColor = color.RGBA{0xff, 0xff, 0xff, 0x30}
+
+// touches turns touch strokes into swipes and taps.
+type touches struct {
+	// start is the position a stroke started or last swiped at
+	start map[int]image.Point
+	// swiped marks strokes which turned at least once
+	swiped map[int]bool
+	// buttons are the on-screen arrow buttons indexed by direction,
+	// nil if disabled
+	buttons []image.Rectangle
+	button  *ebiten.Image
+	opts    ebiten.DrawImageOptions
+}
+
+func newTouches(screenW, screenH int, buttons bool) *touches {
+	t := &touches{
+		start:  make(map[int]image.Point),
+		swiped: make(map[int]bool),
+	}
+	if buttons {
+		s := int(float64(screenH) * touchButtonFraction)
+		// a cross in the bottom right corner
+		cx, cy := screenW-2*s, screenH-2*s
+		t.buttons = []image.Rectangle{
+			image.Rect(cx+s, cy, cx+2*s, cy+s),
+			image.Rect(cx, cy+s, cx+s, cy+2*s),
+			image.Rect(cx-s, cy, cx, cy+s),
+			image.Rect(cx, cy-s, cx+s, cy),
+		}
+		t.button, _ = ebiten.NewImage(s-2, s-2, ebiten.FilterNearest)
+		t.button.Fill(touchButtonColor)
+	}
+	return t
+}
+
+// update tracks the touches of this frame. It returns the direction of
+// a swipe or a held arrow button, and whether a stroke ended without
+// swiping.
+func (t *touches) update(screenH int) (direction int, turned, tapped bool) {
+	for _, id := range inpututil.JustPressedTouchIDs() {
+		x, y := ebiten.TouchPosition(id)
+		t.start[id] = image.Pt(x, y)
+	}
+	min := int(float64(screenH) * swipeFraction)
+	for id, start := range t.start {
+		if inpututil.IsTouchJustReleased(id) {
+			if !t.swiped[id] && t.buttonAt(start) == -1 {
+				tapped = true
+			}
+			delete(t.start, id)
+			delete(t.swiped, id)
+			continue
+		}
+		x, y := ebiten.TouchPosition(id)
+		p := image.Pt(x, y)
+		if d := t.buttonAt(start); d != -1 {
+			direction, turned = d, true
+			continue
+		}
+		d := p.Sub(start)
+		if abs(d.X) < min && abs(d.Y) < min {
+			continue
+		}
+		if abs(d.X) > abs(d.Y) {
+			direction = 2
+			if d.X > 0 {
+				direction = 0
+			}
+		} else {
+			direction = 3
+			if d.Y > 0 {
+				direction = 1
+			}
+		}
+		turned = true
+		// swiping on from here allows turning again within one stroke
+		t.start[id] = p
+		t.swiped[id] = true
+	}
+	return direction, turned, tapped
+}
+
+// buttonAt returns the direction of the arrow button at p, or -1.
+func (t *touches) buttonAt(p image.Point) int {
+	for d, r := range t.buttons {
+		if p.In(r) {
+			return d
+		}
+	}
+	return -1
+}
+
+// draw draws the arrow buttons.
+func (t *touches) draw(canvas *ebiten.Image) {
+	for _, r := range t.buttons {
+		t.opts.GeoM.Reset()
+		t.opts.GeoM.Translate(float64(r.Min.X+1), float64(r.Min.Y+1))
+		canvas.DrawImage(t.button, &t.opts)
+	}
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/wongak/snake/input"
+)
+
+// rawMode switches the terminal to raw mode, so keys arrive unbuffered
+// and are not echoed. It returns a function restoring the previous
+// mode. The modes are set with stty, which keeps the package free of
+// platform specific system calls.
+func rawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// keys maps the byte sequences of the terminal keys to actions.
+var keys = map[string]input.Action{
+	"\x1b[A": input.Up,
+	"\x1b[B": input.Down,
+	"\x1b[C": input.Right,
+	"\x1b[D": input.Left,
+	"w":      input.Up,
+	"s":      input.Down,
+	"d":      input.Right,
+	"a":      input.Left,
+	"p":      input.Pause,
+	" ":      input.Pause,
+	"\r":     input.Restart,
+	"\n":     input.Restart,
+	"\x1b":   input.Quit,
+	"q":      input.Quit,
+	// Ctrl-C does not interrupt in raw mode
+	"\x03": input.Quit,
+}
+
+// readKeys reads keys from the terminal and sends their actions until
+// reading fails. Unknown keys are ignored.
+func readKeys(f *os.File, actions chan<- input.Action) {
+	buf := make([]byte, 64)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			close(actions)
+			return
+		}
+		for b := buf[:n]; len(b) > 0; {
+			l := 1
+			// arrow keys are escape sequences, a lone escape quits
+			if b[0] == 0x1b && len(b) >= 3 && b[1] == '[' {
+				l = 3
+			}
+			if a, ok := keys[strings.ToLower(string(b[:l]))]; ok {
+				actions <- a
+			}
+			b = b[l:]
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package tui plays snake in a terminal. It renders the rules of
+// package game as colored cells with ANSI escape codes, two columns per
+// cell, and reads the keys the terminal sends. The terminal has to
+// support 24-bit colors.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wongak/snake/game"
+	"github.com/wongak/snake/input"
+)
+
+const (
+	clearScreen = "\x1b[2J"
+	home        = "\x1b[H"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	resetColor  = "\x1b[0m"
+	clearLine   = "\x1b[K"
+)
+
+// The colors of the board, those of the default theme of the graphical
+// frontend.
+var (
+	backgroundColor = color.RGBA{0x18, 0x29, 0x18, 0xff}
+	borderColor     = color.RGBA{0x10, 0xa0, 0x10, 0xff}
+	snakeColor      = color.RGBA{0x20, 0xff, 0x20, 0xff}
+	headColor       = color.RGBA{0x90, 0xff, 0x90, 0xff}
+	rivalColor      = color.RGBA{0x30, 0x90, 0xff, 0xff}
+	rivalHeadColor  = color.RGBA{0x90, 0xc8, 0xff, 0xff}
+	foodColor       = color.RGBA{0xa0, 0xa0, 0x10, 0xff}
+	forbiddenColor  = color.RGBA{0xe0, 0x20, 0xe0, 0xff}
+	obstacleColor   = color.RGBA{0x70, 0x70, 0x70, 0xff}
+	powerupColor    = color.RGBA{0xe0, 0xe0, 0xff, 0xff}
+	warningColor    = color.RGBA{0x80, 0x30, 0x18, 0xff}
+)
+
+// foodColors are the colors of the food types by name, others are
+// drawn in foodColor.
+var foodColors = map[string]color.RGBA{
+	"bonus":  {0xff, 0xe0, 0x40, 0xff},
+	"mega":   {0xff, 0x70, 0x30, 0xff},
+	"poison": {0x80, 0x20, 0xa0, 0xff},
+}
+
+// Options configures a game in the terminal.
+type Options struct {
+	Rules game.Rules
+	// Seed seeds every run. With 0 the seed is taken from the clock.
+	Seed int64
+}
+
+// tui is a game running in the terminal.
+type tui struct {
+	options Options
+	sim     *game.Game
+	paused  bool
+	out     *bufio.Writer
+	// cells are the colors of the board including the border, row by
+	// row
+	cells []color.RGBA
+}
+
+// Run plays snake in the terminal of stdin and stdout until the player
+// quits.
+func Run(o Options) error {
+	if o.Rules.TwoPlayer || o.Rules.Coop {
+		return errors.New("the terminal has no two-player modes")
+	}
+	restore, err := rawMode()
+	if err != nil {
+		return fmt.Errorf("could not switch the terminal to raw mode: %v", err)
+	}
+	defer restore()
+	t := &tui{
+		options: o,
+		out:     bufio.NewWriter(os.Stdout),
+	}
+	t.reset()
+	fmt.Fprint(t.out, hideCursor+clearScreen)
+	defer func() {
+		fmt.Fprint(t.out, resetColor+showCursor+"\r\n")
+		t.out.Flush()
+	}()
+
+	actions := make(chan input.Action, 16)
+	go readKeys(os.Stdin, actions)
+	// ebiten updates 60 times per second, the rules count in its frames
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+	for range ticker.C {
+		var turns []game.Turn
+	read:
+		for {
+			select {
+			case a, ok := <-actions:
+				if !ok || a == input.Quit {
+					return nil
+				}
+				turns = t.act(a, turns)
+			default:
+				break read
+			}
+		}
+		if !t.paused {
+			t.sim.Tick(turns...)
+		}
+		t.draw()
+		if err := t.out.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tui) reset() {
+	seed := t.options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.sim = game.New(t.options.Rules, seed)
+	t.paused = false
+}
+
+// act applies an action and returns turns with the turn of a steering
+// action added.
+func (t *tui) act(a input.Action, turns []game.Turn) []game.Turn {
+	sim := t.sim
+	switch {
+	case a == input.Pause && !sim.Over():
+		t.paused = !t.paused
+	case a == input.Restart && sim.Over():
+		t.reset()
+	case a == input.Restart && sim.LevelComplete():
+		sim.NextLevel()
+	case a == input.Restart && t.paused:
+		t.paused = false
+	}
+	d, ok := a.Direction()
+	if !ok || t.paused || t.options.Rules.Autopilot != nil {
+		return turns
+	}
+	if sim.Reversed() {
+		d = (d + 2) % 4
+	}
+	return append(turns, game.Turn{Direction: d})
+}
+
+// draw draws the board and the status line below it.
+func (t *tui) draw() {
+	sim := t.sim
+	cellsX, cellsY := sim.Size()
+	// the border takes a row and a column on each side
+	w, h := cellsX+3, cellsY+3
+	if len(t.cells) != w*h {
+		t.cells = make([]color.RGBA, w*h)
+	}
+	for i := range t.cells {
+		t.cells[i] = backgroundColor
+	}
+	set := func(x, y int, c color.RGBA) {
+		if x >= 0 && x <= cellsX && y >= 0 && y <= cellsY {
+			t.cells[(y+1)*w+x+1] = c
+		}
+	}
+	for x := 0; x < w; x++ {
+		t.cells[x], t.cells[(h-1)*w+x] = borderColor, borderColor
+	}
+	for y := 0; y < h; y++ {
+		t.cells[y*w], t.cells[y*w+w-1] = borderColor, borderColor
+	}
+	if ring, left, ok := sim.NextShrink(); ok && left <= game.ShrinkWarning {
+		// the ring closing next
+		for y := 0; y <= cellsY; y++ {
+			for x := 0; x <= cellsX; x++ {
+				if sim.Ring(x, y) == ring {
+					set(x, y, warningColor)
+				}
+			}
+		}
+	}
+	for _, c := range sim.Obstacles() {
+		set(c.X, c.Y, obstacleColor)
+	}
+	for _, f := range sim.Foods() {
+		c, ok := foodColors[f.Kind.Name]
+		if !ok {
+			c = foodColor
+		}
+		set(f.X, f.Y, c)
+	}
+	for _, p := range sim.Poisons() {
+		set(p.X, p.Y, foodColors[p.Kind.Name])
+	}
+	if f := sim.Forbidden(); f != nil {
+		set(f.X, f.Y, forbiddenColor)
+	}
+	if p := sim.Powerup(); p != nil {
+		set(p.X, p.Y, powerupColor)
+	}
+	drawSnake := func(s *game.Snake, body, head color.RGBA) {
+		s.Each(func(c game.Cell) {
+			set(c.X, c.Y, body)
+		})
+		h := s.Head()
+		set(h.X, h.Y, head)
+	}
+	if r := sim.Rival(); r != nil {
+		drawSnake(r, rivalColor, rivalHeadColor)
+	}
+	drawSnake(sim.Snake(), snakeColor, headColor)
+
+	t.out.WriteString(home)
+	var last color.RGBA
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := t.cells[y*w+x]
+			if x == 0 || c != last {
+				fmt.Fprintf(t.out, "\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+				last = c
+			}
+			t.out.WriteString("  ")
+		}
+		t.out.WriteString(resetColor + "\r\n")
+	}
+	t.out.WriteString(t.status() + clearLine)
+}
+
+// status returns the line shown below the board.
+func (t *tui) status() string {
+	sim, r := t.sim, t.options.Rules
+	str := "score " + t.formatScore(sim.Points())
+	if r.TwoPlayer || r.AI {
+		str = "P1 " + t.formatScore(sim.Points()) + "   rival " + t.formatScore(sim.RivalPoints())
+	}
+	if len(r.Campaign) > 0 && !r.TwoPlayer && !r.Tron {
+		str += fmt.Sprintf("   level %d/%d   food %d/%d", sim.Level()+1, len(r.Campaign), sim.LevelEaten(), game.LevelFood)
+	}
+	if effect, left := sim.Effect(); left > 0 {
+		str += fmt.Sprintf("   %s %.1fs", effect, float64(left)/60)
+	}
+	if sim.Reversed() {
+		str += "   CONTROLS REVERSED!"
+	}
+	if _, left, ok := sim.NextShrink(); ok && left <= game.ShrinkWarning {
+		str += fmt.Sprintf("   WALLS CLOSE IN %d", (left+59)/60)
+	}
+	switch {
+	case sim.Won():
+		str += "   CAMPAIGN COMPLETE - Enter restarts, Esc quits"
+	case sim.Over():
+		str += "   GAME OVER - Enter restarts, Esc quits"
+	case sim.LevelComplete():
+		str += "   LEVEL COMPLETE - Enter continues"
+	case t.paused:
+		str += "   PAUSED - P resumes"
+	}
+	return str
+}
+
+// formatScore formats points, as seconds in tron games.
+func (t *tui) formatScore(points int64) string {
+	if t.options.Rules.Tron {
+		return fmt.Sprintf("%d.%ds", points/10, points%10)
+	}
+	return strconv.FormatInt(points, 10)
+}
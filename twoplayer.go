@@ -0,0 +1,78 @@
+package snake
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"github.com/wongak/snake/game"
+)
+
+// In two-player mode player one steers with the arrow keys and player
+// two with WASD, regardless of the keymap.
+var (
+	playerOneKeys = Keymap{
+		ActionUp:    {ebiten.KeyUp},
+		ActionDown:  {ebiten.KeyDown},
+		ActionLeft:  {ebiten.KeyLeft},
+		ActionRight: {ebiten.KeyRight},
+	}
+	playerTwoKeys = Keymap{
+		ActionUp:    {ebiten.KeyW},
+		ActionDown:  {ebiten.KeyS},
+		ActionLeft:  {ebiten.KeyA},
+		ActionRight: {ebiten.KeyD},
+	}
+)
+
+// steering are the steering actions in the order they are checked,
+// with their snake directions.
+var steering = []struct {
+	action    Action
+	direction int
+}{
+	{ActionUp, 3},
+	{ActionDown, 1},
+	{ActionLeft, 2},
+	{ActionRight, 0},
+}
+
+// cyclePlayers switches from one player to two players to two players
+// together to one player against the computer. Campaigns have no
+// two-player modes, co-op has no tron.
+func (g *Game) cyclePlayers() {
+	o := &g.options
+	switch {
+	case o.TwoPlayer:
+		o.TwoPlayer, o.Coop, o.Tron = false, true, false
+	case o.Coop:
+		o.Coop, o.AI = false, true
+	case o.AI:
+		o.AI = false
+	case o.Campaign:
+		o.AI = true
+	default:
+		o.TwoPlayer = true
+	}
+}
+
+// playerTurns reads the turns of both players in two-player mode.
+func (g *Game) playerTurns() []game.Turn {
+	var turns []game.Turn
+	for _, st := range steering {
+		if playerOneKeys.pressed(st.action) {
+			turns = append(turns, g.control(st.direction))
+		}
+		if playerTwoKeys.pressed(st.action) {
+			turns = append(turns, game.Turn{Player: 1, Direction: st.direction})
+		}
+	}
+	return turns
+}
+
+// twoPlayerOver shows the winner of an ended two-player game.
+func (g *Game) twoPlayerOver() {
+	g.state = StateGameOver
+	g.recordLifetime()
+	if g.options.OnGameOver != nil {
+		g.options.OnGameOver(g.Stats())
+	}
+	g.endRecording()
+}